@@ -0,0 +1,249 @@
+package evaluator
+
+import (
+	"fmt"
+	"toy_interpreter_go/lexer"
+	"toy_interpreter_go/object"
+)
+
+// standardBuiltins : the functions every program can call, a variable of the same name hides them
+var standardBuiltins = []*object.Builtin{
+	{Name: "len", MinArgs: 1, MaxArgs: 1, Fn: builtinLen},
+	{Name: "push", MinArgs: 2, MaxArgs: 2, Fn: builtinPush},
+	{Name: "slice", MinArgs: 2, MaxArgs: 3, Fn: builtinSlice},
+	{Name: "keys", MinArgs: 1, MaxArgs: 1, Fn: builtinKeys},
+	{Name: "values", MinArgs: 1, MaxArgs: 1, Fn: builtinValues},
+	{Name: "has", MinArgs: 2, MaxArgs: 2, Fn: builtinHas},
+	{Name: "delete", MinArgs: 2, MaxArgs: 2, Fn: builtinDelete},
+	{Name: "abs", MinArgs: 1, MaxArgs: 1, Fn: builtinAbs},
+	{Name: "min", MinArgs: 1, MaxArgs: object.VARIADIC, Fn: builtinMin},
+	{Name: "max", MinArgs: 1, MaxArgs: object.VARIADIC, Fn: builtinMax},
+	{Name: "pow", MinArgs: 2, MaxArgs: 2, Fn: builtinPow},
+}
+
+// builtins : the registry every new evaluator copies its builtins from
+var builtins = make(map[string]*object.Builtin)
+
+func init() {
+	for _, builtin := range standardBuiltins {
+		RegisterBuiltin(builtin)
+	}
+}
+
+// RegisterBuiltin : make a Go function callable from the programs of every evaluator created afterwards,
+// replacing a builtin of the same name; meant to be called during initialization, it is not safe
+// to call while programs run. It panics if the builtin has no function, an invalid arity, or a name
+// that is not an identifier
+func RegisterBuiltin(builtin *object.Builtin) {
+	checkBuiltin(builtin)
+	builtins[builtin.Name] = builtin
+}
+
+// RegisterBuiltin : make a Go function callable from the programs of this evaluator only,
+// it panics like the RegisterBuiltin function
+func (eval *Evaluator) RegisterBuiltin(builtin *object.Builtin) {
+	checkBuiltin(builtin)
+	eval.builtins[builtin.Name] = builtin
+}
+
+func checkBuiltin(builtin *object.Builtin) {
+	tok := lexer.LexConstructor(builtin.Name).NextToken()
+	if tok.Type != lexer.IDENT || tok.Val != builtin.Name {
+		panic(fmt.Sprintf("evaluator: builtin name %q is not an identifier", builtin.Name))
+	}
+	if builtin.Fn == nil {
+		panic(fmt.Sprintf("evaluator: builtin %s has no function", builtin.Name))
+	}
+	if builtin.MinArgs < 0 || (builtin.MaxArgs != object.VARIADIC && builtin.MaxArgs < builtin.MinArgs) {
+		panic(fmt.Sprintf("evaluator: builtin %s has an invalid arity %d to %d",
+			builtin.Name, builtin.MinArgs, builtin.MaxArgs))
+	}
+}
+
+// len(x) : number of elements of an array, pairs of a hash or bytes of a string
+func builtinLen(_ object.CallContext, args ...object.Object) object.Object {
+	switch arg := args[0].(type) {
+	case *object.Array:
+		return &object.Integer{Value: int64(len(arg.Elements))}
+	case *object.String:
+		return &object.Integer{Value: int64(len(arg.Value))}
+	case *object.Hash:
+		return &object.Integer{Value: int64(len(arg.Pairs))}
+	default:
+		return object.ArgumentError("len", 0, arg, object.ARRAY_OBJ, object.STRING_OBJ, object.HASH_OBJ)
+	}
+}
+
+// push(array, value) : appends value to the array and returns the array
+func builtinPush(_ object.CallContext, args ...object.Object) object.Object {
+	array, ok := args[0].(*object.Array)
+	if !ok {
+		return object.ArgumentError("push", 0, args[0], object.ARRAY_OBJ)
+	}
+	array.Elements = append(array.Elements, args[1])
+	return array
+}
+
+// slice(x, start) or slice(x, start, end) : a new array or string with the elements
+// from start up to, but not including, end, which defaults to the length of x
+func builtinSlice(_ object.CallContext, args ...object.Object) object.Object {
+	var length int64
+	switch arg := args[0].(type) {
+	case *object.Array:
+		length = int64(len(arg.Elements))
+	case *object.String:
+		length = int64(len(arg.Value))
+	default:
+		return object.ArgumentError("slice", 0, arg, object.ARRAY_OBJ, object.STRING_OBJ)
+	}
+
+	bounds := []int64{0, length}
+	for i, arg := range args[1:] {
+		integer, ok := arg.(*object.Integer)
+		if !ok {
+			return object.ArgumentError("slice", i+1, arg, object.INTEGER_OBJ)
+		}
+		bounds[i] = integer.Value
+	}
+
+	start, end := bounds[0], bounds[1]
+	if start < 0 || end < start || end > length {
+		return object.NewError(object.INDEX_RANGE, "slice bounds [%d:%d] out of range for length %d", start, end, length)
+	}
+
+	switch arg := args[0].(type) {
+	case *object.Array:
+		elements := make([]object.Object, end-start)
+		copy(elements, arg.Elements[start:end])
+		return &object.Array{Elements: elements}
+	default:
+		return &object.String{Value: arg.(*object.String).Value[start:end]}
+	}
+}
+
+// keys(hash) : array of the keys, integers first in increasing order, then strings
+func builtinKeys(_ object.CallContext, args ...object.Object) object.Object {
+	hash, ok := args[0].(*object.Hash)
+	if !ok {
+		return object.ArgumentError("keys", 0, args[0], object.HASH_OBJ)
+	}
+
+	pairs := hash.Sorted()
+	elements := make([]object.Object, len(pairs))
+	for i, pair := range pairs {
+		elements[i] = pair.Key
+	}
+	return &object.Array{Elements: elements}
+}
+
+// values(hash) : array of the values, in the order of keys(hash)
+func builtinValues(_ object.CallContext, args ...object.Object) object.Object {
+	hash, ok := args[0].(*object.Hash)
+	if !ok {
+		return object.ArgumentError("values", 0, args[0], object.HASH_OBJ)
+	}
+
+	pairs := hash.Sorted()
+	elements := make([]object.Object, len(pairs))
+	for i, pair := range pairs {
+		elements[i] = pair.Value
+	}
+	return &object.Array{Elements: elements}
+}
+
+// has(hash, key) : 1 if the hash has the key, 0 otherwise
+func builtinHas(_ object.CallContext, args ...object.Object) object.Object {
+	hash, key, err := hashAndKey("has", args)
+	if err != nil {
+		return err
+	}
+
+	_, ok := hash.Get(key)
+	return nativeBoolToInteger(ok)
+}
+
+// delete(hash, key) : removes the key if the hash has it and returns the hash
+func builtinDelete(_ object.CallContext, args ...object.Object) object.Object {
+	hash, key, err := hashAndKey("delete", args)
+	if err != nil {
+		return err
+	}
+
+	hash.Delete(key)
+	return hash
+}
+
+// the hash and key arguments of has and delete
+func hashAndKey(name string, args []object.Object) (*object.Hash, object.Hashable, *object.Error) {
+	hash, ok := args[0].(*object.Hash)
+	if !ok {
+		return nil, nil, object.ArgumentError(name, 0, args[0], object.HASH_OBJ)
+	}
+	key, ok := args[1].(object.Hashable)
+	if !ok {
+		return nil, nil, object.ArgumentError(name, 1, args[1], object.INTEGER_OBJ, object.STRING_OBJ)
+	}
+	return hash, key, nil
+}
+
+// abs(n) : the absolute value of an integer
+func builtinAbs(_ object.CallContext, args ...object.Object) object.Object {
+	n, ok := args[0].(*object.Integer)
+	if !ok {
+		return object.ArgumentError("abs", 0, args[0], object.INTEGER_OBJ)
+	}
+	if n.Value < 0 {
+		return &object.Integer{Value: -n.Value}
+	}
+	return n
+}
+
+// min(a, ...) : the smallest of its integer arguments
+func builtinMin(_ object.CallContext, args ...object.Object) object.Object {
+	return extremum("min", args, func(a, b int64) bool { return a < b })
+}
+
+// max(a, ...) : the largest of its integer arguments
+func builtinMax(_ object.CallContext, args ...object.Object) object.Object {
+	return extremum("max", args, func(a, b int64) bool { return a > b })
+}
+
+// the first argument that no other one is better than
+func extremum(name string, args []object.Object, better func(a, b int64) bool) object.Object {
+	var best *object.Integer
+	for i, arg := range args {
+		n, ok := arg.(*object.Integer)
+		if !ok {
+			return object.ArgumentError(name, i, arg, object.INTEGER_OBJ)
+		}
+		if best == nil || better(n.Value, best.Value) {
+			best = n
+		}
+	}
+	return best
+}
+
+// pow(base, exponent) : base raised to a non-negative exponent, overflowing like the other operators
+func builtinPow(_ object.CallContext, args ...object.Object) object.Object {
+	base, ok := args[0].(*object.Integer)
+	if !ok {
+		return object.ArgumentError("pow", 0, args[0], object.INTEGER_OBJ)
+	}
+	exponent, ok := args[1].(*object.Integer)
+	if !ok {
+		return object.ArgumentError("pow", 1, args[1], object.INTEGER_OBJ)
+	}
+	if exponent.Value < 0 {
+		return object.NewError(object.WRONG_ARGUMENTS, "pow needs a non-negative exponent, got %d", exponent.Value)
+	}
+
+	// exponentiation by squaring
+	result, b := int64(1), base.Value
+	for e := exponent.Value; e > 0; e >>= 1 {
+		if e&1 == 1 {
+			result *= b
+		}
+		b *= b
+	}
+	return &object.Integer{Value: result}
+}
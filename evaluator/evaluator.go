@@ -1,81 +1,311 @@
 package evaluator
 
 import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"toy_interpreter_go/lexer"
 	"toy_interpreter_go/object"
 	"toy_interpreter_go/tree"
-	"fmt"
 )
 
-// Eval : evaluation function
+// PrintSeparator : written between the values of a print statement
+const PrintSeparator = " "
+
+// signals of break and continue, they carry no state
+var (
+	BREAK    = &object.Break{}
+	CONTINUE = &object.Continue{}
+)
+
+// DefaultMaxCallDepth : nested function calls allowed before a runtime error
+const DefaultMaxCallDepth = 1000
+
+// Evaluator : walks the tree and streams the output of print statements
+type Evaluator struct {
+	out *bufio.Writer // where print statements write, flushed after every line
+
+	MaxCallDepth int // nested function calls allowed, stops runaway recursion
+	depth        int // current number of nested function calls
+
+	builtins   map[string]*object.Builtin // the registered builtins and the ones of this evaluator
+	programEnv *object.Environment        // environment of the program being evaluated
+	earlier    map[string]bool            // names bound in programEnv by earlier programs, they may be declared again
+}
+
+// EvalConstructor : constructor function of an evaluator writing to out
+func EvalConstructor(out io.Writer) *Evaluator {
+	eval := &Evaluator{
+		out:          bufio.NewWriter(out),
+		MaxCallDepth: DefaultMaxCallDepth,
+		builtins:     make(map[string]*object.Builtin, len(builtins)),
+	}
+	for name, builtin := range builtins {
+		eval.builtins[name] = builtin
+	}
+	return eval
+}
+
+// Flush : write any buffered output to the underlying writer
+func (eval *Evaluator) Flush() error {
+	return eval.out.Flush()
+}
+
+// EvalContext : Eval that stops with a CANCELLED error once ctx is done, ctx is checked
+// before every iteration of a loop and every function call
+func (eval *Evaluator) EvalContext(ctx context.Context, node tree.TreeNode, env *object.Environment) object.Object {
+	return eval.evalNode(ctx, node, env)
+}
+
+// Eval : evaluation function, runs until the program ends
+func (eval *Evaluator) Eval(node tree.TreeNode, env *object.Environment) object.Object {
+	return eval.evalNode(context.Background(), node, env)
+}
+
+// evaluate a node, ctx is passed down to the loops and calls that check it
 // Uncomment printing messages to check if we walk the tree correctly
-func Eval(node tree.TreeNode, env *object.Environment) object.Object {
+func (eval *Evaluator) evalNode(ctx context.Context, node tree.TreeNode, env *object.Environment) object.Object {
 	switch node := node.(type) {
 	case *tree.Root:
 		// fmt.Println("Evaluate Root")
-		return evalProgram(node, env)
+		return eval.evalProgram(ctx, node, env)
 	case *tree.ExpressionStatement:
 		// fmt.Println("Evaluate expression statement")
-		return Eval(node.Expression, env)
+		return eval.evalNode(ctx, node.Expression, env)
 	case *tree.IntegerLiteral:
 		// fmt.Println("Evaluate integer")
 		return &object.Integer{Value: node.Value}
+	case *tree.StringLiteral:
+		return &object.String{Value: node.Value}
+	case *tree.ArrayLiteral:
+		elements := make([]object.Object, len(node.Elements))
+		for i, element := range node.Elements {
+			elements[i] = eval.evalValue(ctx, element, env)
+			if isError(elements[i]) {
+				return elements[i]
+			}
+		}
+		return &object.Array{Elements: elements}
+	case *tree.HashLiteral:
+		return eval.evalHashLiteral(ctx, node, env)
+	case *tree.IndexExpression:
+		left := eval.evalValue(ctx, node.Left, env)
+		if isError(left) {
+			return left
+		}
+		index := eval.evalValue(ctx, node.Index, env)
+		if isError(index) {
+			return index
+		}
+		return evalIndexExpression(node, left, index)
+	case *tree.PrefixExpression:
+		right := eval.evalValue(ctx, node.Right, env)
+		if isError(right) {
+			return right
+		}
+		return evalPrefixExpression(node, right)
 	case *tree.InfixExpression:
-		left := Eval(node.Left, env)
-		right := Eval(node.Right, env)
-		return evalInfixExpression(node.Operator, left, right)
+		if node.Operator == "&&" || node.Operator == "||" {
+			return eval.evalLogicalExpression(ctx, node, env)
+		}
+		left := eval.evalValue(ctx, node.Left, env)
+		if isError(left) {
+			return left
+		}
+		right := eval.evalValue(ctx, node.Right, env)
+		if isError(right) {
+			return right
+		}
+		return evalInfixExpression(node, left, right)
 	case *tree.BlockStatement:
-		return evalBlockStatement(node, env)
+		return eval.evalBlockStatement(ctx, node, env)
 	case *tree.WhileExpression:
 		// fmt.Println("Evaluate While expression")
-		return evalWhileExpression(node, env)
+		return eval.evalWhileExpression(ctx, node, env)
+	case *tree.ForExpression:
+		return eval.evalForExpression(ctx, node, env)
+	case *tree.DoWhileExpression:
+		return eval.evalDoWhileExpression(ctx, node, env)
 	case *tree.IfExpression:
 		// fmt.Println("Evaluate If expression")
-		return evalIfExpression(node, env)
+		return eval.evalIfExpression(ctx, node, env)
+	case *tree.FunctionStatement:
+		fn := &object.Function{Name: node.Name.Value, Parameters: node.Parameters, Body: node.Body, Env: env}
+		if !eval.declare(env, node.Name.Value, fn) {
+			return newError(node.Name.Pos(), object.REDECLARED,
+				"%s is already declared in this scope", node.Name.Value)
+		}
+	case *tree.FunctionLiteral:
+		// the function captures the current environment
+		return &object.Function{Parameters: node.Parameters, Body: node.Body, Env: env}
+	case *tree.ReturnStatement:
+		if node.Value == nil {
+			return &object.ReturnValue{}
+		}
+		val := eval.evalValue(ctx, node.Value, env)
+		if isError(val) {
+			return val
+		}
+		return &object.ReturnValue{Value: val}
+	case *tree.CallExpression:
+		return eval.evalCallExpression(ctx, node, env)
+	case *tree.BreakStatement:
+		return BREAK
+	case *tree.ContinueStatement:
+		return CONTINUE
 	case *tree.PrintStatement:
 		// fmt.Println("Evaluate Print")
-		val := Eval(node.Value, env)
-		return &object.PrintValue{Value: val}
+		return eval.evalPrintStatement(ctx, node, env)
 	case *tree.AssignStatement:
 		// fmt.Println("Evaluate assignment")
-		val := Eval(node.Value, env)
-		env.Set(node.Name.Value, val)
+		val := eval.evalValue(ctx, node.Value, env)
+		if isError(val) {
+			return val
+		}
+		env.Assign(node.Name.Value, val)
+	case *tree.IndexAssignStatement:
+		return eval.evalIndexAssignStatement(ctx, node, env)
+	case *tree.VarStatement:
+		val := eval.evalValue(ctx, node.Value, env)
+		if isError(val) {
+			return val
+		}
+		if !eval.declare(env, node.Name.Value, val) {
+			return newError(node.Name.Pos(), object.REDECLARED,
+				"%s is already declared in this scope", node.Name.Value)
+		}
 	case *tree.Identifier:
 		// fmt.Println("Evaluate identifier")
-		return evalIdentifier(node, env)
+		return eval.evalIdentifier(node, env)
 	}
 
 	return nil
 }
 
-func evalProgram(program *tree.Root, env *object.Environment) object.Object {
+func (eval *Evaluator) evalProgram(ctx context.Context, program *tree.Root, env *object.Environment) object.Object {
 	var result object.Object
 
+	// make sure everything printed by the program reaches the writer
+	defer eval.Flush()
+
+	outerEnv, outerEarlier := eval.programEnv, eval.earlier
+	eval.programEnv, eval.earlier = env, make(map[string]bool)
+	for _, name := range env.Names() {
+		eval.earlier[name] = true
+	}
+	defer func() { eval.programEnv, eval.earlier = outerEnv, outerEarlier }()
+
 	for _, statement := range program.Statements {
-		result = Eval(statement, env)
+		result = eval.evalNode(ctx, statement, env)
+
+		// a runtime error stops the program
+		if isError(result) {
+			return result
+		}
 	}
 	return result
 }
 
+// declare name in env, false if it is already declared there
+// a global name bound by an earlier program in the same environment, like a line of the repl
+// or an earlier Run of an embedded script, can be declared again once
+func (eval *Evaluator) declare(env *object.Environment, name string, val object.Object) bool {
+	if env == eval.programEnv && eval.earlier[name] {
+		delete(eval.earlier, name)
+		env.Set(name, val)
+		return true
+	}
+	return env.Declare(name, val)
+}
+
+// evaluate an expression whose value is used, it is an error if it has none
+func (eval *Evaluator) evalValue(ctx context.Context, node tree.Expression, env *object.Environment) object.Object {
+	val := eval.evalNode(ctx, node, env)
+	if val == nil {
+		return newError(node.Pos(), object.NO_VALUE, "%s has no value", node.String())
+	}
+	return val
+}
+
+// write the values of a print statement on one line as soon as it is executed
+// nothing is written if one of the values is an error
+func (eval *Evaluator) evalPrintStatement(
+	ctx context.Context,
+	ps *tree.PrintStatement,
+	env *object.Environment,
+) object.Object {
+	values := make([]string, len(ps.Values))
+	for i, value := range ps.Values {
+		val := eval.evalValue(ctx, value, env)
+		if isError(val) {
+			return val
+		}
+		values[i] = val.Inspect()
+	}
+
+	for i, val := range values {
+		if i > 0 {
+			eval.out.WriteString(PrintSeparator)
+		}
+		eval.out.WriteString(val)
+	}
+	eval.out.WriteByte('\n')
+	// a pipeline sees the line now, not when the program ends
+	eval.out.Flush()
+	return nil
+}
+
+func evalPrefixExpression(node *tree.PrefixExpression, right object.Object) object.Object {
+	if node.Operator == "!" {
+		return nativeBoolToInteger(!isTruthy(right))
+	}
+
+	integer, ok := right.(*object.Integer)
+	if !ok {
+		return newError(node.Token.Pos, object.UNKNOWN_OPERATOR,
+			"unknown operator: %s%s", node.Operator, right.Type())
+	}
+
+	switch node.Operator {
+	case "-":
+		return &object.Integer{Value: -integer.Value}
+	case "+":
+		return integer
+	default:
+		return newError(node.Token.Pos, object.UNKNOWN_OPERATOR,
+			"unknown operator: %s%s", node.Operator, right.Type())
+	}
+}
+
 func evalInfixExpression(
-	operator string,
+	node *tree.InfixExpression,
 	left, right object.Object,
 ) object.Object {
 	switch {
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
-		return evalIntegerInfixExpression(operator, left, right)
+		return evalIntegerInfixExpression(node, left, right)
+	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
+		return evalStringInfixExpression(node, left, right)
+	case left.Type() != right.Type():
+		return newError(node.Token.Pos, object.TYPE_MISMATCH,
+			"type mismatch: %s %s %s", left.Type(), node.Operator, right.Type())
 	default:
-		return nil
+		return newError(node.Token.Pos, object.UNKNOWN_OPERATOR,
+			"unknown operator: %s %s %s", left.Type(), node.Operator, right.Type())
 	}
 }
 
 func evalIntegerInfixExpression(
-	operator string,
+	node *tree.InfixExpression,
 	left, right object.Object,
 ) object.Object {
 	leftVal := left.(*object.Integer).Value
 	rightVal := right.(*object.Integer).Value
 
-	switch operator {
+	switch operator := node.Operator; operator {
 	case "+":
 		return &object.Integer{Value: leftVal + rightVal}
 	case "-":
@@ -83,8 +313,14 @@ func evalIntegerInfixExpression(
 	case "*":
 		return &object.Integer{Value: leftVal * rightVal}
 	case "/":
+		if rightVal == 0 {
+			return newError(node.Token.Pos, object.ZERO_DIVISION, "division by zero")
+		}
 		return &object.Integer{Value: leftVal / rightVal}
 	case "%":
+		if rightVal == 0 {
+			return newError(node.Token.Pos, object.ZERO_DIVISION, "modulo by zero")
+		}
 		return &object.Integer{Value: leftVal % rightVal}
 	case ">":
 		if leftVal > rightVal {
@@ -116,81 +352,506 @@ func evalIntegerInfixExpression(
 			return &object.Integer{Value: 1}
 		}
 		return &object.Integer{Value: 0}
-	case "||":
-		if leftVal == 1 || rightVal == 1 {
-			return &object.Integer{Value: 1}
+	default:
+		return newError(node.Token.Pos, object.UNKNOWN_OPERATOR,
+			"unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// && and || as in C: the right operand is only evaluated when the left one
+// does not decide the result, any nonzero value is true and the result is 1 or 0
+func (eval *Evaluator) evalLogicalExpression(
+	ctx context.Context,
+	node *tree.InfixExpression,
+	env *object.Environment,
+) object.Object {
+	left := eval.evalValue(ctx, node.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	if isTruthy(left) == (node.Operator == "||") {
+		return nativeBoolToInteger(node.Operator == "||")
+	}
+
+	right := eval.evalValue(ctx, node.Right, env)
+	if isError(right) {
+		return right
+	}
+	return nativeBoolToInteger(isTruthy(right))
+}
+
+// + concatenates, the comparisons compare byte by byte
+func evalStringInfixExpression(
+	node *tree.InfixExpression,
+	left, right object.Object,
+) object.Object {
+	leftVal := left.(*object.String).Value
+	rightVal := right.(*object.String).Value
+
+	switch node.Operator {
+	case "+":
+		return &object.String{Value: leftVal + rightVal}
+	case "==":
+		return nativeBoolToInteger(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToInteger(leftVal != rightVal)
+	case "<":
+		return nativeBoolToInteger(leftVal < rightVal)
+	case "<=":
+		return nativeBoolToInteger(leftVal <= rightVal)
+	case ">":
+		return nativeBoolToInteger(leftVal > rightVal)
+	case ">=":
+		return nativeBoolToInteger(leftVal >= rightVal)
+	default:
+		return newError(node.Token.Pos, object.UNKNOWN_OPERATOR,
+			"unknown operator: %s %s %s", left.Type(), node.Operator, right.Type())
+	}
+}
+
+// the keys are evaluated in the order of the program, a later duplicate key wins
+func (eval *Evaluator) evalHashLiteral(
+	ctx context.Context,
+	node *tree.HashLiteral,
+	env *object.Environment,
+) object.Object {
+	hash := object.NewHash()
+
+	for _, pair := range node.Pairs {
+		key := eval.evalValue(ctx, pair.Key, env)
+		if isError(key) {
+			return key
 		}
-		return &object.Integer{Value: 0}
-	case "&&":
-		if leftVal == 1 && rightVal == 1 {
-			return &object.Integer{Value: 1}
+		hashable, err := hashKey(pair.Key, key)
+		if err != nil {
+			return err
 		}
-		return &object.Integer{Value: 0}
+
+		value := eval.evalValue(ctx, pair.Value, env)
+		if isError(value) {
+			return value
+		}
+		hash.Set(hashable, value)
+	}
+	return hash
+}
+
+// array[index], string[index] or hash[key], a string gives a string of one byte
+// reading a key that the hash does not have is an error
+func evalIndexExpression(node *tree.IndexExpression, left, index object.Object) object.Object {
+	switch left := left.(type) {
+	case *object.Hash:
+		key, err := hashKey(node.Index, index)
+		if err != nil {
+			return err
+		}
+		value, ok := left.Get(key)
+		if !ok {
+			return newError(node.Index.Pos(), object.KEY_NOT_FOUND, "key %s not found", describeKey(key))
+		}
+		return value
+	case *object.Array:
+		i, err := checkIndex(node, index, len(left.Elements))
+		if err != nil {
+			return err
+		}
+		return left.Elements[i]
+	case *object.String:
+		i, err := checkIndex(node, index, len(left.Value))
+		if err != nil {
+			return err
+		}
+		return &object.String{Value: left.Value[i : i+1]}
 	default:
-		return nil
+		return newError(node.Token.Pos, object.UNKNOWN_OPERATOR, "index operator not supported: %s", left.Type())
 	}
 }
 
-func evalIfExpression(ie *tree.IfExpression, env *object.Environment) object.Object {
-	condition := Eval(ie.Condition, env)
+// target[index] = value, only arrays and hashes can be changed
+// an array keeps its length, a hash gets the key if it does not have it
+func (eval *Evaluator) evalIndexAssignStatement(
+	ctx context.Context,
+	node *tree.IndexAssignStatement,
+	env *object.Environment,
+) object.Object {
+	left := eval.evalValue(ctx, node.Target.Left, env)
+	if isError(left) {
+		return left
+	}
+	index := eval.evalValue(ctx, node.Target.Index, env)
+	if isError(index) {
+		return index
+	}
+	val := eval.evalValue(ctx, node.Value, env)
+	if isError(val) {
+		return val
+	}
 
-	// printing messages to check if the correct branch is evaluated
-	if checkCondition(condition) {
-		fmt.Println("Evaluating true branch")
-		return Eval(ie.TrueBranch, env)
+	switch left := left.(type) {
+	case *object.Array:
+		i, err := checkIndex(node.Target, index, len(left.Elements))
+		if err != nil {
+			return err
+		}
+		left.Elements[i] = val
+	case *object.Hash:
+		key, err := hashKey(node.Target.Index, index)
+		if err != nil {
+			return err
+		}
+		left.Set(key, val)
+	default:
+		return newError(node.Target.Token.Pos, object.UNKNOWN_OPERATOR,
+			"index assignment not supported: %s", left.Type())
+	}
+	return nil
+}
+
+// only integers and strings can be keys of a hash
+func hashKey(node tree.Expression, key object.Object) (object.Hashable, *object.Error) {
+	hashable, ok := key.(object.Hashable)
+	if !ok {
+		return nil, newError(node.Pos(), object.TYPE_MISMATCH, "unusable as hash key: %s", key.Type())
+	}
+	return hashable, nil
+}
+
+// a key as written in the program, strings in double quotes
+func describeKey(key object.Hashable) string {
+	if s, ok := key.(*object.String); ok {
+		return strconv.Quote(s.Value)
+	}
+	return key.Inspect()
+}
+
+// the index must be an integer from 0 to length - 1
+func checkIndex(node *tree.IndexExpression, index object.Object, length int) (int, *object.Error) {
+	integer, ok := index.(*object.Integer)
+	if !ok {
+		return 0, newError(node.Index.Pos(), object.TYPE_MISMATCH, "index must be INTEGER, got %s", index.Type())
+	}
+	if integer.Value < 0 || integer.Value >= int64(length) {
+		return 0, newError(node.Index.Pos(), object.INDEX_RANGE,
+			"index %d out of range for length %d", integer.Value, length)
+	}
+	return int(integer.Value), nil
+}
+
+func (eval *Evaluator) evalIfExpression(
+	ctx context.Context,
+	ie *tree.IfExpression,
+	env *object.Environment,
+) object.Object {
+	condition := eval.evalValue(ctx, ie.Condition, env)
+	if isError(condition) {
+		return condition
+	}
+
+	if isTruthy(condition) {
+		return eval.evalNode(ctx, ie.TrueBranch, env)
 	} else if ie.FalseBranch != nil {
-		fmt.Println("Evaluating false branch")
-		return Eval(ie.FalseBranch, env)
+		return eval.evalNode(ctx, ie.FalseBranch, env)
 	} else {
 		return nil
 	}
 }
 
-func evalWhileExpression(we *tree.WhileExpression, env *object.Environment) object.Object {
+func (eval *Evaluator) evalWhileExpression(
+	ctx context.Context,
+	we *tree.WhileExpression,
+	env *object.Environment,
+) object.Object {
 	for {
-		condition := Eval(we.Condition, env)
+		if err := CheckContext(ctx, we.Token.Pos); err != nil {
+			return err
+		}
+
+		condition := eval.evalValue(ctx, we.Condition, env)
+		if isError(condition) {
+			return condition
+		}
 
-		if checkCondition(condition) {
-			rt := Eval(we.Action, env)
+		if !isTruthy(condition) {
+			break
+		}
 
-			if rt != nil {
+		rt := eval.evalNode(ctx, we.Action, env)
+		if isError(rt) || isReturnValue(rt) {
+			return rt
+		}
+		if rt == BREAK {
+			break
+		}
+		// a continue just ends the body early
+	}
+	return nil
+}
+
+// the step runs after every iteration, also after a continue
+// a variable declared in the init is local to the loop
+func (eval *Evaluator) evalForExpression(
+	ctx context.Context,
+	fe *tree.ForExpression,
+	env *object.Environment,
+) object.Object {
+	env = object.NewBlockEnvironment(env)
+
+	if fe.Init != nil {
+		if rt := eval.evalNode(ctx, fe.Init, env); isError(rt) {
+			return rt
+		}
+	}
+
+	for {
+		if err := CheckContext(ctx, fe.Token.Pos); err != nil {
+			return err
+		}
+
+		// a missing condition is always true
+		if fe.Condition != nil {
+			condition := eval.evalValue(ctx, fe.Condition, env)
+			if isError(condition) {
+				return condition
+			}
+			if !isTruthy(condition) {
+				break
+			}
+		}
+
+		rt := eval.evalNode(ctx, fe.Action, env)
+		if isError(rt) || isReturnValue(rt) {
+			return rt
+		}
+		if rt == BREAK {
+			break
+		}
+
+		if fe.Step != nil {
+			if rt := eval.evalNode(ctx, fe.Step, env); isError(rt) {
 				return rt
 			}
-		} else {
+		}
+	}
+	return nil
+}
+
+// the body runs once before the condition is checked, a continue goes to the condition
+func (eval *Evaluator) evalDoWhileExpression(
+	ctx context.Context,
+	de *tree.DoWhileExpression,
+	env *object.Environment,
+) object.Object {
+	for {
+		if err := CheckContext(ctx, de.Token.Pos); err != nil {
+			return err
+		}
+
+		rt := eval.evalNode(ctx, de.Action, env)
+		if isError(rt) || isReturnValue(rt) {
+			return rt
+		}
+		if rt == BREAK {
+			break
+		}
+
+		condition := eval.evalValue(ctx, de.Condition, env)
+		if isError(condition) {
+			return condition
+		}
+		if !isTruthy(condition) {
 			break
 		}
 	}
 	return nil
 }
 
-func evalBlockStatement(block *tree.BlockStatement, env *object.Environment) object.Object {
+// run the statements of a block in its own scope
+func (eval *Evaluator) evalBlockStatement(
+	ctx context.Context,
+	block *tree.BlockStatement,
+	env *object.Environment,
+) object.Object {
+	return eval.evalStatements(ctx, block.Statements, object.NewBlockEnvironment(env))
+}
+
+// a runtime error, return, break or continue stops the statements
+// and is passed up to the enclosing function or loop
+func (eval *Evaluator) evalStatements(
+	ctx context.Context,
+	statements []tree.Statement,
+	env *object.Environment,
+) object.Object {
 	var result object.Object
 
-	for _, statement := range block.Statements {
-		result = Eval(statement, env)
+	for _, statement := range statements {
+		result = eval.evalNode(ctx, statement, env)
 
-		if result != nil {
+		if isError(result) || isReturnValue(result) || result == BREAK || result == CONTINUE {
 			return result
 		}
 	}
 	return result
 }
 
-func checkCondition(obj object.Object) bool {
-	switch obj.Inspect() {
-	case "1":
-		return true
-	case "0":
-		return false
-	default:
-		return false
+func (eval *Evaluator) evalCallExpression(
+	ctx context.Context,
+	call *tree.CallExpression,
+	env *object.Environment,
+) object.Object {
+	function := eval.evalValue(ctx, call.Function, env)
+	if isError(function) {
+		return function
 	}
+
+	args := make([]object.Object, len(call.Arguments))
+	for i, argument := range call.Arguments {
+		args[i] = eval.evalValue(ctx, argument, env)
+		if isError(args[i]) {
+			return args[i]
+		}
+	}
+
+	return eval.applyFunction(ctx, call, function, args)
 }
 
-func evalIdentifier(node *tree.Identifier, env *object.Environment) object.Object {
-	val, ok := env.Get(node.Value)
+// run the body of a function in a new environment holding its parameters
+func (eval *Evaluator) applyFunction(
+	ctx context.Context,
+	call *tree.CallExpression,
+	function object.Object,
+	args []object.Object,
+) object.Object {
+	if err := CheckContext(ctx, call.Pos()); err != nil {
+		return err
+	}
+
+	if builtin, ok := function.(*object.Builtin); ok {
+		if !builtin.Accepts(len(args)) {
+			return newError(call.Pos(), object.WRONG_ARGUMENTS,
+				"%s takes %s argument(s), got %d", builtin.Name, builtin.Arity(), len(args))
+		}
+		result := builtin.Fn(&builtinCall{eval: eval, ctx: ctx, call: call}, args...)
+		if err, ok := result.(*object.Error); ok && !err.Pos.IsValid() {
+			// the builtin may return the same error at every call, it is not changed
+			located := *err
+			located.Pos = call.Pos()
+			return &located
+		}
+		return result
+	}
+
+	fn, ok := function.(*object.Function)
 	if !ok {
+		return newError(call.Pos(), object.NOT_A_FUNCTION, "not a function: %s", function.Type())
+	}
+
+	name := fn.Name
+	if name == "" {
+		name = "fn"
+	}
+
+	if len(args) != len(fn.Parameters) {
+		return newError(call.Pos(), object.WRONG_ARGUMENTS,
+			"%s takes %d argument(s), got %d", name, len(fn.Parameters), len(args))
+	}
+
+	if eval.depth >= eval.MaxCallDepth {
+		return newError(call.Pos(), object.CALL_DEPTH,
+			"maximum call depth of %d exceeded in %s", eval.MaxCallDepth, name)
+	}
+	eval.depth++
+	defer func() { eval.depth-- }()
+
+	callEnv := object.NewEnclosedEnvironment(fn.Env)
+	for i, param := range fn.Parameters {
+		callEnv.Set(param.Value, args[i])
+	}
+
+	// the parameters are in the same scope as the outermost declarations of the body
+	result := eval.evalStatements(ctx, fn.Body.Statements, callEnv)
+	if rv, ok := result.(*object.ReturnValue); ok {
+		return rv.Value
+	}
+	if isError(result) {
+		return result
+	}
+	// falling off the end of the body returns no value
+	return nil
+}
+
+// builtinCall : the object.CallContext of a builtin called at call
+type builtinCall struct {
+	eval *Evaluator
+	ctx  context.Context
+	call *tree.CallExpression
+}
+
+func (bc *builtinCall) Context() context.Context { return bc.ctx }
+
+// Apply : errors are reported at the call of the builtin, the function runs under the same
+// call depth limit and context as the builtin
+func (bc *builtinCall) Apply(fn object.Object, args ...object.Object) object.Object {
+	return bc.eval.applyFunction(bc.ctx, bc.call, fn, args)
+}
+
+// CheckContext : the CANCELLED error at pos if ctx is done, nil otherwise
+func CheckContext(ctx context.Context, pos lexer.Position) *object.Error {
+	err := ctx.Err()
+	switch {
+	case err == nil:
 		return nil
+	case errors.Is(err, context.DeadlineExceeded):
+		rtErr := newError(pos, object.CANCELLED, "time limit exceeded")
+		rtErr.Err = err
+		return rtErr
+	default:
+		rtErr := newError(pos, object.CANCELLED, "execution cancelled")
+		rtErr.Err = err
+		return rtErr
 	}
-	return val
+}
+
+// as in C, any nonzero integer is true, and a string is true unless it is empty
+func isTruthy(obj object.Object) bool {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return obj.Value != 0
+	case *object.String:
+		return obj.Value != ""
+	default:
+		return true
+	}
+}
+
+func nativeBoolToInteger(value bool) *object.Integer {
+	if value {
+		return &object.Integer{Value: 1}
+	}
+	return &object.Integer{Value: 0}
+}
+
+// a variable hides the builtin of the same name
+func (eval *Evaluator) evalIdentifier(node *tree.Identifier, env *object.Environment) object.Object {
+	val, ok := env.Get(node.Value)
+	if ok {
+		return val
+	}
+	if builtin, ok := eval.builtins[node.Value]; ok {
+		return builtin
+	}
+	return newError(node.Pos(), object.UNDEFINED_IDENT, "identifier not found: %s", node.Value)
+}
+
+// create a runtime error at the given position
+func newError(pos lexer.Position, kind object.ErrorKind, format string, args ...interface{}) *object.Error {
+	return &object.Error{Kind: kind, Message: fmt.Sprintf(format, args...), Pos: pos}
+}
+
+func isError(obj object.Object) bool {
+	return obj != nil && obj.Type() == object.ERROR_OBJ
+}
+
+func isReturnValue(obj object.Object) bool {
+	return obj != nil && obj.Type() == object.RETURN_VALUE_OBJ
 }
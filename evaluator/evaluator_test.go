@@ -0,0 +1,446 @@
+package evaluator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"toy_interpreter_go/lexer"
+	"toy_interpreter_go/object"
+	"toy_interpreter_go/parser"
+)
+
+// run a program, returns what it printed and its result
+func run(t *testing.T, input string) (string, object.Object) {
+	t.Helper()
+
+	pars := parser.ParsConstructor(lexer.LexConstructor(input))
+	program := pars.ParseProgram()
+	for _, err := range pars.Errors() {
+		t.Fatalf("%q: unexpected parse error: %s", input, err)
+	}
+
+	var out bytes.Buffer
+	result := EvalConstructor(&out).Eval(program, object.NewEnvironment())
+	return out.String(), result
+}
+
+func TestPrintSelfContainingValues(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a = [1]\npush(a, a)\nprint a", "[1, [...]]\n"},
+		{"a = [1]\nb = [a, a]\nprint b", "[[1], [1]]\n"},
+		{"a = [1]\nb = [a]\npush(a, b)\nprint a, b", "[1, [[...]]] [[1, [...]]]\n"},
+		{"h = {}\nh[1] = h\nprint h", "{1: {...}}\n"},
+		{"h = {\"a\": [0]}\nh[\"a\"][0] = h\nprint h", "{\"a\": [{...}]}\n"},
+		{"a = [{}]\na[0][1] = a\nprint a", "[{1: [...]}]\n"},
+	}
+
+	for _, tt := range tests {
+		out, result := run(t, tt.input)
+		if rtErr, ok := result.(*object.Error); ok {
+			t.Errorf("%q: unexpected runtime error: %s", tt.input, rtErr)
+		}
+		if out != tt.expected {
+			t.Errorf("%q: expected output %q, got %q", tt.input, tt.expected, out)
+		}
+	}
+}
+
+func TestBuiltinErrorPosition(t *testing.T) {
+	// a builtin returning the same error at every call
+	sentinel := object.NewError(object.WRONG_ARGUMENTS, "always fails")
+	fail := &object.Builtin{Name: "fail", MinArgs: 0, MaxArgs: 0, Fn: func(object.CallContext, ...object.Object) object.Object {
+		return sentinel
+	}}
+
+	for _, input := range []string{"fail()", "x = 1\n\n   fail()"} {
+		program := parser.ParsConstructor(lexer.LexConstructor(input)).ParseProgram()
+
+		eval := EvalConstructor(&bytes.Buffer{})
+		eval.RegisterBuiltin(fail)
+		result := eval.Eval(program, object.NewEnvironment())
+
+		rtErr, ok := result.(*object.Error)
+		if !ok || rtErr.Message != "always fails" {
+			t.Fatalf("%q: expected the error of the builtin, got %v", input, result)
+		}
+		if got := program.Statements[len(program.Statements)-1].Pos(); rtErr.Pos != got {
+			t.Errorf("%q: expected the error at %s, got %s", input, got, rtErr.Pos)
+		}
+	}
+	if sentinel.Pos.IsValid() {
+		t.Errorf("expected the error of the builtin to stay without a position, got %s", sentinel.Pos)
+	}
+}
+
+// map(array, f) : a new array of f applied to every element
+func builtinMap(call object.CallContext, args ...object.Object) object.Object {
+	array, ok := args[0].(*object.Array)
+	if !ok {
+		return object.ArgumentError("map", 0, args[0], object.ARRAY_OBJ)
+	}
+	elements := make([]object.Object, len(array.Elements))
+	for i, element := range array.Elements {
+		elements[i] = call.Apply(args[1], element)
+		if elements[i] == nil {
+			return object.NewError(object.NO_VALUE, "map: the function returned no value")
+		}
+		if rtErr, ok := elements[i].(*object.Error); ok {
+			return rtErr
+		}
+	}
+	return &object.Array{Elements: elements}
+}
+
+func TestBuiltinApply(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"print map([1, 2, 3], fn(x) { return x * 2 })", "[2, 4, 6]"},
+		{"print map([-1, 2], abs)", "[1, 2]"},
+		{"n = 10\nprint map([1], fn(x) { return x + n })", "[11]"},
+		{"print map([[1], [2, 3]], fn(a) { return map(a, fn(x) { return -x }) })", "[[-1], [-2, -3]]"},
+		{"print map([1], fn(x) { return x / 0 })", "1:33: runtime error: division by zero"},
+		{"print map([1], fn(x, y) { return x })", "1:7: runtime error: fn takes 2 argument(s), got 1"},
+		{"print map([1], 2)", "1:7: runtime error: not a function: INTEGER"},
+		{"print map([1], fn(x) { x })", "1:7: runtime error: map: the function returned no value"},
+		{"func f(n) { return map([n], f) }\nf(1)", "1:20: runtime error: maximum call depth of 1000 exceeded in f"},
+	}
+
+	for _, tt := range tests {
+		program := parser.ParsConstructor(lexer.LexConstructor(tt.input)).ParseProgram()
+
+		var out bytes.Buffer
+		eval := EvalConstructor(&out)
+		eval.RegisterBuiltin(&object.Builtin{Name: "map", MinArgs: 2, MaxArgs: 2, Fn: builtinMap})
+		result := eval.Eval(program, object.NewEnvironment())
+
+		got := strings.TrimSuffix(out.String(), "\n")
+		if rtErr, ok := result.(*object.Error); ok {
+			got = rtErr.Error()
+		}
+		if got != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestBuiltinContext(t *testing.T) {
+	// a builtin that waits until the program is cancelled
+	wait := &object.Builtin{Name: "wait", MinArgs: 0, MaxArgs: 0,
+		Fn: func(call object.CallContext, args ...object.Object) object.Object {
+			<-call.Context().Done()
+			return &object.Error{Kind: object.CANCELLED, Message: "wait cancelled", Err: call.Context().Err()}
+		}}
+
+	program := parser.ParsConstructor(lexer.LexConstructor("x = 1\nwait()")).ParseProgram()
+	eval := EvalConstructor(&bytes.Buffer{})
+	eval.RegisterBuiltin(wait)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	result := eval.EvalContext(ctx, program, object.NewEnvironment())
+
+	rtErr, ok := result.(*object.Error)
+	if !ok || rtErr.Kind != object.CANCELLED || !errors.Is(rtErr.Err, context.DeadlineExceeded) {
+		t.Fatalf("expected the CANCELLED error of the builtin, got %v", result)
+	}
+	if rtErr.Pos.Line != 2 || rtErr.Pos.Column != 1 {
+		t.Errorf("expected the error at the call 2:1, got %s", rtErr.Pos)
+	}
+}
+
+// a writer that calls seen with everything written to it
+type watchWriter struct {
+	seen func(p []byte)
+}
+
+func (w watchWriter) Write(p []byte) (int, error) {
+	w.seen(p)
+	return len(p), nil
+}
+
+func TestPrintIsWrittenBeforeTheProgramEnds(t *testing.T) {
+	pars := parser.ParsConstructor(lexer.LexConstructor("print \"start\"\nwhile (1) {}"))
+	program := pars.ParseProgram()
+
+	// the loop only ends if the line reaches the writer while it runs
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var out bytes.Buffer
+	writer := watchWriter{seen: func(p []byte) {
+		out.Write(p)
+		if bytes.HasSuffix(p, []byte("\n")) {
+			cancel()
+		}
+	}}
+	result := EvalConstructor(writer).EvalContext(ctx, program, object.NewEnvironment())
+
+	rtErr, ok := result.(*object.Error)
+	if !ok || rtErr.Kind != object.CANCELLED || !errors.Is(rtErr.Err, context.Canceled) {
+		t.Fatalf("expected the loop to be cancelled by the written line, got %v", result)
+	}
+	if out.String() != "start\n" {
+		t.Errorf("expected output %q, got %q", "start\n", out.String())
+	}
+}
+
+func TestCancellation(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"while loop", "while (1) {}"},
+		{"for loop", "for (;;) {}"},
+		{"do-while loop", "do {} while (1)"},
+		// depth 30 stays below the call depth limit, but the calls never end
+		{"recursion", "func f(n) {\n if (n == 0) return 0\n return f(n - 1) + f(n - 1)\n}\nf(30)"},
+	}
+
+	for _, tt := range tests {
+		pars := parser.ParsConstructor(lexer.LexConstructor(tt.input))
+		program := pars.ParseProgram()
+		if errs := pars.Errors(); len(errs) > 0 {
+			t.Fatalf("%s: unexpected parse error: %s", tt.name, errs[0])
+		}
+
+		eval := EvalConstructor(&bytes.Buffer{})
+		eval.MaxCallDepth = 1 << 30
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		result := eval.EvalContext(ctx, program, object.NewEnvironment())
+		cancel()
+
+		rtErr, ok := result.(*object.Error)
+		if !ok || rtErr.Kind != object.CANCELLED {
+			t.Errorf("%s: expected a CANCELLED error, got %v", tt.name, result)
+			continue
+		}
+		if !errors.Is(rtErr.Err, context.DeadlineExceeded) {
+			t.Errorf("%s: expected the error to carry context.DeadlineExceeded, got %v", tt.name, rtErr.Err)
+		}
+	}
+}
+
+func TestCancelledBeforeStart(t *testing.T) {
+	pars := parser.ParsConstructor(lexer.LexConstructor("print 1\nwhile (1) {}"))
+	program := pars.ParseProgram()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var out bytes.Buffer
+	result := EvalConstructor(&out).EvalContext(ctx, program, object.NewEnvironment())
+
+	rtErr, ok := result.(*object.Error)
+	if !ok || rtErr.Kind != object.CANCELLED || rtErr.Message != "execution cancelled" {
+		t.Fatalf("expected an execution cancelled error, got %v", result)
+	}
+	if !errors.Is(rtErr.Err, context.Canceled) {
+		t.Errorf("expected the error to carry context.Canceled, got %v", rtErr.Err)
+	}
+	// statements before the first loop or call still run and their output is flushed
+	if out.String() != "1\n" {
+		t.Errorf("expected output %q, got %q", "1\n", out.String())
+	}
+}
+
+// outputTest : a program and what it prints, followed by the runtime error that stops it, if any
+type outputTest struct {
+	input    string
+	expected string
+}
+
+func checkOutput(t *testing.T, tests []outputTest) {
+	t.Helper()
+
+	for _, tt := range tests {
+		out, result := run(t, tt.input)
+		if rtErr, ok := result.(*object.Error); ok {
+			out += rtErr.Error() + "\n"
+		}
+		if out != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.input, tt.expected, out)
+		}
+	}
+}
+
+func TestBreakContinue(t *testing.T) {
+	checkOutput(t, []outputTest{
+		{"i = 0\nwhile (i < 5) {\n  i = i + 1\n  if (i == 2) continue\n  if (i == 4) break\n  print i\n}\nprint i", "1\n3\n4\n"},
+		// break and continue leave nested blocks, but only the innermost loop
+		{"i = 0\nwhile (i < 2) {\n  i = i + 1\n  j = 0\n  while (1) {\n    j = j + 1\n    { { if (j > 2) break } }\n  }\n  print i, j\n}", "1 3\n2 3\n"},
+		{"i = 0\nwhile (i < 3) {\n  i = i + 1\n  {\n    var k = i\n    if (k == 2) continue\n    print k\n  }\n}", "1\n3\n"},
+		{"while (1) break\nprint 1", "1\n"},
+		{"i = 0\nwhile (1) {\n  i = i + 1\n  if (i < 3) continue\n  break\n}\nprint i", "3\n"},
+	})
+}
+
+func TestForDoWhile(t *testing.T) {
+	checkOutput(t, []outputTest{
+		{"for (i = 0; i < 3; i = i + 1) print i", "0\n1\n2\n"},
+		// a continue still runs the step, a break does not
+		{"for (i = 0; i < 5; i = i + 1) {\n  if (i % 2 == 0) continue\n  print i\n}", "1\n3\n"},
+		{"for (i = 0; ; i = i + 1) if (i == 2) break\nprint i", "2\n"},
+		{"i = 0\nfor (;;) {\n  i = i + 1\n  if (i == 3) break\n}\nprint i", "3\n"},
+		{"for (i = 5; i < 3; i = i + 1) print i\nprint i", "5\n"},
+		// a variable declared in the init is local to the loop
+		{"i = 9\nfor (var i = 0; i < 2; i = i + 1) {}\nprint i", "9\n"},
+		{"for (var i = 0; i < 2; i = i + 1) {}\nprint i", "2:7: runtime error: identifier not found: i\n"},
+		// the body of a do-while runs before the condition, a continue goes to the condition
+		{"do print 1 while (0)", "1\n"},
+		{"i = 0\ndo {\n  i = i + 1\n  if (i < 3) continue\n  print i\n} while (i < 5)", "3\n4\n5\n"},
+		{"i = 0\ndo {\n  i = i + 1\n  if (i == 2) break\n} while (1)\nprint i", "2\n"},
+		{"do print 1 while (x)", "1\n1:19: runtime error: identifier not found: x\n"},
+	})
+}
+
+func TestFunctions(t *testing.T) {
+	checkOutput(t, []outputTest{
+		{"func fact(n) {\n  if (n <= 1) return 1\n  return n * fact(n - 1)\n}\nprint fact(5)", "120\n"},
+		// return leaves every loop and block of the function
+		{"func find(n) {\n  for (i = 0; ; i = i + 1) {\n    while (1) {\n      if (i == n) return i * 10\n      break\n    }\n  }\n}\nprint find(3)", "30\n"},
+		{"func f() {\n  print 1\n  return\n  print 2\n}\nf()", "1\n"},
+		{"func f() {\n  do return 4 while (1)\n}\nprint f()", "4\n"},
+		// the parameters are local to the call
+		{"a = 1\nfunc f(a) { return a * 2 }\nprint f(5), a", "10 1\n"},
+		{"func f() { return }\nx = f()", "2:5: runtime error: f() has no value\n"},
+		{"func f() {}\nprint f()", "2:7: runtime error: f() has no value\n"},
+		{"func f(a) { return a }\nf(1, 2)", "2:1: runtime error: f takes 1 argument(s), got 2\n"},
+		{"x = 1\nx()", "2:1: runtime error: not a function: INTEGER\n"},
+		{"func f(n) { return f(n + 1) }\nf(0)", "1:20: runtime error: maximum call depth of 1000 exceeded in f\n"},
+		// the depth of returned calls is given back
+		{"func f(n) {\n  if (n > 0) return f(n - 1)\n  return 0\n}\nfor (i = 0; i < 3; i = i + 1) f(900)\nprint 1", "1\n"},
+	})
+}
+
+func TestScopes(t *testing.T) {
+	checkOutput(t, []outputTest{
+		// an assignment to a name bound nowhere creates a variable of the function call or the program
+		{"if (1) { x = 2 }\nprint x", "2\n"},
+		{"func f() {\n  if (1) {\n    y = 1\n  }\n  print y\n}\nf()\nprint y", "1\n8:7: runtime error: identifier not found: y\n"},
+		{"x = 1\nfunc f() { x = 2 }\nf()\nprint x", "2\n"},
+		{"func f() {\n  while (1) {\n    { n = 3 }\n    break\n  }\n  return n\n}\nprint f()", "3\n"},
+		// var hides the outer variable until the end of the block, assignments go to the closest one
+		{"x = 1\n{\n  var x = 2\n  x = 3\n  print x\n}\nprint x", "3\n1\n"},
+		{"x = 1\n{\n  var x = 2\n  {\n    x = 4\n  }\n  print x\n}\nprint x", "4\n1\n"},
+		{"x = 1\nfunc f() {\n  var x = 5\n  return x\n}\nprint f(), x", "5 1\n"},
+		{"{\n  var a = 1\n  { var a = 2 }\n  print a\n}", "1\n"},
+		// a function sees the variables of the scope it was declared in
+		{"{\n  var x = 7\n  func g() { return x }\n  print g()\n}\nprint g()", "7\n6:7: runtime error: identifier not found: g\n"},
+		{"{\n  var a = 1\n  var a = 2\n}", "3:7: runtime error: a is already declared in this scope\n"},
+		{"var a = 1\na = 2\nvar a = 3", "3:5: runtime error: a is already declared in this scope\n"},
+		{"a = 1\nvar a = 2", "2:5: runtime error: a is already declared in this scope\n"},
+		{"func f(a) {\n  var a = 2\n}\nf(1)", "2:7: runtime error: a is already declared in this scope\n"},
+		{"func f() {}\nfunc f() {}", "2:6: runtime error: f is already declared in this scope\n"},
+	})
+}
+
+// a global name bound by an earlier program in the same environment can be declared again once
+func TestDeclareAgain(t *testing.T) {
+	tests := []struct {
+		programs []string
+		expected string
+	}{
+		{[]string{"var a = 1\nfunc f() { return 1 }", "var a = 2\nfunc f() { return 2 }\nprint a, f()"}, "2 2\n"},
+		{[]string{"a = 1", "var a = 2\nvar a = 3"}, "2:5: runtime error: a is already declared in this scope\n"},
+		{[]string{"var a = 1", "var a = 2", "var a = 3\nprint a"}, "3\n"},
+		// only the global scope
+		{[]string{"var a = 1", "func f() {\n  var a = 2\n  var a = 3\n}\nf()"}, "3:7: runtime error: a is already declared in this scope\n"},
+		// a name of the program itself is not declared by an earlier one
+		{[]string{"b = 1", "var a = 1\nvar a = 2"}, "2:5: runtime error: a is already declared in this scope\n"},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		eval := EvalConstructor(&out)
+		env := object.NewEnvironment()
+
+		for _, input := range tt.programs {
+			program := parser.ParsConstructor(lexer.LexConstructor(input)).ParseProgram()
+			if rtErr, ok := eval.Eval(program, env).(*object.Error); ok {
+				out.WriteString(rtErr.Error() + "\n")
+				break
+			}
+		}
+		if out.String() != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.programs, tt.expected, out.String())
+		}
+	}
+}
+
+func TestClosures(t *testing.T) {
+	checkOutput(t, []outputTest{
+		{"func counter() {\n  var n = 0\n  return fn() {\n    n = n + 1\n    return n\n  }\n}\na = counter()\nb = counter()\na()\nprint a(), b()", "2 1\n"},
+		{"print fn(a, b) { return a + b }(1, 2)", "3\n"},
+		{"add = fn(a) { return fn(b) { return a + b } }\nprint add(1)(2)", "3\n"},
+		// a closure sees later changes of the variables it captured
+		{"x = 1\nf = fn() { return x }\nx = 2\nprint f()", "2\n"},
+		// every iteration of a loop body has its own scope, the init of a for has one for the whole loop
+		{"fs = []\nfor (var i = 0; i < 3; i = i + 1) {\n  var j = i\n  push(fs, fn() { return j })\n}\nprint fs[0](), fs[1](), fs[2]()", "0 1 2\n"},
+		{"fs = []\nfor (var i = 0; i < 3; i = i + 1) push(fs, fn() { return i })\nprint fs[0](), fs[2]()", "3 3\n"},
+		// a name bound nowhere is local to the call of the closure
+		{"f = fn() { z = 1 }\nf()\nprint z", "3:7: runtime error: identifier not found: z\n"},
+		{"f = fn(x) { return x }\nf()", "2:1: runtime error: fn takes 1 argument(s), got 0\n"},
+		{"print fn() {}", "fn()\n"},
+	})
+}
+
+func TestStrings(t *testing.T) {
+	checkOutput(t, []outputTest{
+		{"print \"a\" + \"b\", \"\" + \"c\"", "ab c\n"},
+		{"print \"a\" < \"b\", \"b\" <= \"a\", \"abc\" == \"abc\", \"a\" != \"a\", \"b\" > \"a\", \"a\" >= \"b\"", "1 0 1 0 1 0\n"},
+		{"print \"Z\" < \"a\", \"ab\" < \"b\", \"a\" < \"ab\"", "1 1 1\n"},
+		{"print \"tab\\there\", [\"q\\\"\"]", "tab\there [\"q\\\"\"]\n"},
+		// a string is true unless it is empty
+		{"if (\"\") print 1 else print 2\nprint !\"\", !\"x\", \"\" || \"a\", \"a\" && \"\"", "2\n1 0 1 0\n"},
+		{"s = \"abc\"\nprint s[1], len(s), slice(s, 1)", "b 3 bc\n"},
+		{"print \"n=\" + 1", "1:12: runtime error: type mismatch: STRING + INTEGER\n"},
+		{"print 1 == \"1\"", "1:9: runtime error: type mismatch: INTEGER == STRING\n"},
+		{"print \"a\" - \"b\"", "1:11: runtime error: unknown operator: STRING - STRING\n"},
+		{"print -\"a\"", "1:7: runtime error: unknown operator: -STRING\n"},
+		{"s = \"abc\"\nprint s[3]", "2:9: runtime error: index 3 out of range for length 3\n"},
+	})
+}
+
+func TestHashes(t *testing.T) {
+	checkOutput(t, []outputTest{
+		// integers first in increasing order, then strings, whatever the order of the literal
+		{"h = {\"b\": 1, 2: 2, \"a\": 3, 1: 4}\nprint h, keys(h), values(h)", "{1: 4, 2: 2, \"a\": 3, \"b\": 1} [1, 2, \"a\", \"b\"] [4, 2, 3, 1]\n"},
+		{"h = {10: 0, -1: 0, 2: 0, \"B\": 0, \"a\": 0}\nh[0] = 1\nprint keys(h)", "[-1, 0, 2, 10, \"B\", \"a\"]\n"},
+		{"h = {1: \"int\", \"1\": \"str\"}\nprint h[1], h[\"1\"], len(h)", "int str 2\n"},
+		{"print {1: 1, 1: 2}", "{1: 2}\n"},
+		{"h = {\"a\": 1}\nprint has(h, \"a\"), has(h, \"b\")\ndelete(h, \"a\")\ndelete(h, \"b\")\nprint h, len(h)", "1 0\n{} 0\n"},
+		{"h = {}\ng = h\ng[\"k\"] = 1\nprint h", "{\"k\": 1}\n"},
+		{"h = {}\nprint h[\"x\"]", "2:9: runtime error: key \"x\" not found\n"},
+		{"h = {[1]: 2}", "1:6: runtime error: unusable as hash key: ARRAY\n"},
+		{"h = {}\nh[{}] = 1", "2:3: runtime error: unusable as hash key: HASH\n"},
+		{"print has({}, [])", "1:7: runtime error: argument 2 of has must be INTEGER or STRING, got ARRAY\n"},
+	})
+}
+
+func TestBuiltins(t *testing.T) {
+	checkOutput(t, []outputTest{
+		{"print abs(-3), min(3, 1, 2), max(3, 1, 2), min(4), pow(2, 10), pow(7, 0)", "3 1 3 4 1024 1\n"},
+		{"print len([1, 2]), len(\"abc\"), len({1: 1}), len", "2 3 1 builtin len\n"},
+		// a variable or function of the same name hides a builtin
+		{"len = 5\nprint len", "5\n"},
+		{"func abs(x) { return 0 }\nprint abs(-3)", "0\n"},
+		{"print len()", "1:7: runtime error: len takes 1 argument(s), got 0\n"},
+		{"x = slice([1], 0, 1, 2)", "1:5: runtime error: slice takes 2 to 3 argument(s), got 4\n"},
+		{"print min()", "1:7: runtime error: min takes at least 1 argument(s), got 0\n"},
+		{"print min(1, \"a\")", "1:7: runtime error: argument 2 of min must be INTEGER, got STRING\n"},
+		{"print len(1)", "1:7: runtime error: argument 1 of len must be ARRAY or STRING or HASH, got INTEGER\n"},
+		{"print pow(2, -1)", "1:7: runtime error: pow needs a non-negative exponent, got -1\n"},
+		{"print slice([1, 2, 3], 2, 1)", "1:7: runtime error: slice bounds [2:1] out of range for length 3\n"},
+		// the arguments are evaluated before the number of arguments is checked
+		{"print abs(1, x)", "1:14: runtime error: identifier not found: x\n"},
+	})
+}
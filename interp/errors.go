@@ -0,0 +1,46 @@
+package interp
+
+import (
+	"errors"
+	"strings"
+	"toy_interpreter_go/lexer"
+	"toy_interpreter_go/object"
+	"toy_interpreter_go/parser"
+)
+
+// ErrUndefined : GetVar of a variable that was never assigned
+var ErrUndefined = errors.New("undefined")
+
+// SyntaxError : the program has syntax errors and was not run
+type SyntaxError struct {
+	Errors []*parser.ParseError // in source order
+}
+
+// Error : every syntax error in file:line:col: message form, one per line
+func (e *SyntaxError) Error() string {
+	lines := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		lines[i] = err.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// RuntimeError : the program stopped with a runtime error
+type RuntimeError struct {
+	Kind    object.ErrorKind
+	Message string
+	Pos     lexer.Position // where in the program the error happened
+	Err     error          // the Go error that caused it, if any, like context.DeadlineExceeded
+}
+
+func newRuntimeError(err *object.Error) *RuntimeError {
+	return &RuntimeError{Kind: err.Kind, Message: err.Message, Pos: err.Pos, Err: err.Err}
+}
+
+// Error : the error in file:line:col: runtime error: message form
+func (e *RuntimeError) Error() string {
+	return e.Pos.String() + ": runtime error: " + e.Message
+}
+
+// Unwrap : the Go error that caused the runtime error, so that errors.Is(err, context.DeadlineExceeded) works
+func (e *RuntimeError) Unwrap() error { return e.Err }
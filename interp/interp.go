@@ -0,0 +1,126 @@
+package interp
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"toy_interpreter_go/evaluator"
+	"toy_interpreter_go/lexer"
+	"toy_interpreter_go/object"
+	"toy_interpreter_go/parser"
+)
+
+// Interpreter : runs programs from Go, the global variables live across Run calls
+// like in the repl; an Interpreter must not be used by several goroutines at once
+type Interpreter struct {
+	eval  *evaluator.Evaluator
+	env   *object.Environment
+	stdin *bufio.Reader
+
+	stdout       io.Writer
+	maxCallDepth int
+	builtins     []*object.Builtin
+}
+
+// Option : configures an Interpreter in New
+type Option func(*Interpreter)
+
+// WithStdout : where print statements write, os.Stdout by default
+func WithStdout(w io.Writer) Option {
+	return func(in *Interpreter) { in.stdout = w }
+}
+
+// WithStdin : where the input builtin reads lines from, os.Stdin by default
+func WithStdin(r io.Reader) Option {
+	return func(in *Interpreter) { in.stdin = bufio.NewReader(r) }
+}
+
+// WithMaxCallDepth : nested function calls allowed before a runtime error,
+// evaluator.DefaultMaxCallDepth by default
+func WithMaxCallDepth(n int) Option {
+	return func(in *Interpreter) { in.maxCallDepth = n }
+}
+
+// WithBuiltin : a Go function the programs of this interpreter can call,
+// New panics like evaluator.RegisterBuiltin if it is invalid
+func WithBuiltin(builtin *object.Builtin) Option {
+	return func(in *Interpreter) { in.builtins = append(in.builtins, builtin) }
+}
+
+// New : an interpreter with no variables
+func New(opts ...Option) *Interpreter {
+	in := &Interpreter{
+		env:          object.NewEnvironment(),
+		stdout:       os.Stdout,
+		maxCallDepth: evaluator.DefaultMaxCallDepth,
+	}
+	for _, opt := range opts {
+		opt(in)
+	}
+	if in.stdin == nil {
+		in.stdin = bufio.NewReader(os.Stdin)
+	}
+
+	in.eval = evaluator.EvalConstructor(in.stdout)
+	in.eval.MaxCallDepth = in.maxCallDepth
+	in.eval.RegisterBuiltin(&object.Builtin{Name: "input", MinArgs: 0, MaxArgs: 0, Fn: in.input})
+	for _, builtin := range in.builtins {
+		in.eval.RegisterBuiltin(builtin)
+	}
+	return in
+}
+
+// Run : parse and run a program, see RunFile
+func (in *Interpreter) Run(ctx context.Context, src string) error {
+	return in.RunFile(ctx, "", src)
+}
+
+// RunFile : parse and run a program whose positions are reported in the file name
+// a program with syntax errors is not run and gives a *SyntaxError, a runtime error
+// gives a *RuntimeError; the output printed before the error is kept
+// the program stops with a CANCELLED runtime error, which wraps ctx.Err(), once ctx is done,
+// and does not start if ctx is done already
+// the variables assigned at the top level of the program stay for the next Run, which
+// may declare them again with var or func, so the same script can run many times
+func (in *Interpreter) RunFile(ctx context.Context, name, src string) error {
+	// nothing runs, the error is at the start of the program
+	if rtErr := evaluator.CheckContext(ctx, lexer.Position{File: name, Line: 1, Column: 1}); rtErr != nil {
+		return newRuntimeError(rtErr)
+	}
+
+	pars := parser.ParsConstructor(lexer.LexFileConstructor(name, src))
+	program := pars.ParseProgram()
+	if errs := pars.Errors(); len(errs) > 0 {
+		return &SyntaxError{Errors: errs}
+	}
+
+	evaluated := in.eval.EvalContext(ctx, program, in.env)
+	if err := in.eval.Flush(); err != nil {
+		return err
+	}
+	if rtErr, ok := evaluated.(*object.Error); ok {
+		return newRuntimeError(rtErr)
+	}
+	return nil
+}
+
+// Reset : forget every global variable, including the ones of SetVar
+func (in *Interpreter) Reset() {
+	in.env = object.NewEnvironment()
+}
+
+// input() : the next line of the standard input without its line break, "" at the end of the input
+func (in *Interpreter) input(_ object.CallContext, args ...object.Object) object.Object {
+	// let a prompt printed just before appear first
+	in.eval.Flush()
+
+	line, err := in.stdin.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return object.NewError(object.IO_ERROR, "input: %s", err)
+	}
+	line = strings.TrimSuffix(line, "\n")
+	line = strings.TrimSuffix(line, "\r")
+	return &object.String{Value: line}
+}
@@ -0,0 +1,281 @@
+package interp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"toy_interpreter_go/object"
+)
+
+// an interpreter writing to a buffer, with an empty standard input
+func newTest(opts ...Option) (*Interpreter, *bytes.Buffer) {
+	var out bytes.Buffer
+	opts = append([]Option{WithStdout(&out), WithStdin(strings.NewReader(""))}, opts...)
+	return New(opts...), &out
+}
+
+func mustRun(t *testing.T, in *Interpreter, src string) {
+	t.Helper()
+	if err := in.Run(context.Background(), src); err != nil {
+		t.Fatalf("%q: unexpected error: %s", src, err)
+	}
+}
+
+func TestGetVarSelfContaining(t *testing.T) {
+	in, _ := newTest()
+	mustRun(t, in, "a = [1]\npush(a, a)\nh = {}\nh[\"h\"] = [h]\nb = [1]\nshared = [b, b]")
+
+	for _, name := range []string{"a", "h"} {
+		if _, err := in.GetVar(name); err == nil || !strings.Contains(err.Error(), "contains itself") {
+			t.Errorf("GetVar(%q): expected an error for a value that contains itself, got %v", name, err)
+		}
+	}
+
+	// the same array twice is not a cycle
+	if _, err := in.GetVar("shared"); err != nil {
+		t.Errorf("GetVar(\"shared\"): unexpected error: %s", err)
+	}
+}
+
+func TestRunTwice(t *testing.T) {
+	in, out := newTest()
+	src := "var n = 1\nfunc f(x) { return x + n }\nprint f(1)"
+	mustRun(t, in, src)
+	mustRun(t, in, src)
+	if out.String() != "2\n2\n" {
+		t.Errorf("expected output %q, got %q", "2\n2\n", out.String())
+	}
+
+	// a second declaration in the same program is still an error
+	err := in.Run(context.Background(), "func f() {}\nfunc f() {}")
+	if err == nil || !strings.Contains(err.Error(), "f is already declared in this scope") {
+		t.Errorf("expected a redeclaration error, got %v", err)
+	}
+
+	in.Reset()
+	if _, err := in.GetVar("n"); err == nil {
+		t.Errorf("expected n to be forgotten by Reset")
+	}
+}
+
+func TestSetVarGetVar(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected interface{} // what GetVar gives back
+	}{
+		{int64(-7), int64(-7)},
+		{3, int64(3)},
+		{true, int64(1)},
+		{false, int64(0)},
+		{"a \"b\"", "a \"b\""},
+		{[]interface{}{1, "x", []interface{}{}}, []interface{}{int64(1), "x", []interface{}{}}},
+		{map[string]interface{}{"k": 2}, map[interface{}]interface{}{"k": int64(2)}},
+		{map[int64]interface{}{5: "v"}, map[interface{}]interface{}{int64(5): "v"}},
+	}
+
+	for _, tt := range tests {
+		in, _ := newTest()
+		if err := in.SetVar("v", tt.value); err != nil {
+			t.Fatalf("SetVar(%#v): unexpected error: %s", tt.value, err)
+		}
+		got, err := in.GetVar("v")
+		if err != nil {
+			t.Fatalf("GetVar after SetVar(%#v): unexpected error: %s", tt.value, err)
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("SetVar(%#v): GetVar gave %#v, expected %#v", tt.value, got, tt.expected)
+		}
+	}
+}
+
+func TestSetVarGetVarErrors(t *testing.T) {
+	in, _ := newTest()
+
+	for _, name := range []string{"", "x1", "if", "a b"} {
+		if err := in.SetVar(name, 1); err == nil {
+			t.Errorf("SetVar(%q): expected an error for a name that is not an identifier", name)
+		}
+	}
+	if err := in.SetVar("f", 1.5); err == nil {
+		t.Errorf("SetVar of a float64: expected an error")
+	}
+	if err := in.SetVar("xs", []interface{}{1, struct{}{}}); err == nil {
+		t.Errorf("SetVar of an array with an unsupported element: expected an error")
+	}
+
+	if _, err := in.GetVar("missing"); !errors.Is(err, ErrUndefined) {
+		t.Errorf("GetVar of a missing variable: expected ErrUndefined, got %v", err)
+	}
+	mustRun(t, in, "f = fn() {}")
+	if _, err := in.GetVar("f"); err == nil {
+		t.Errorf("GetVar of a function: expected an error")
+	}
+}
+
+func TestGlobalsPersist(t *testing.T) {
+	in, out := newTest()
+	if err := in.SetVar("limit", int64(3)); err != nil {
+		t.Fatal(err)
+	}
+
+	mustRun(t, in, "total = 0\nfor (var i = 0; i < limit; i = i + 1) total = total + i")
+	mustRun(t, in, "total = total * 10\nprint total")
+
+	if got, _ := in.GetVar("total"); got != int64(30) {
+		t.Errorf("expected total to be 30, got %#v", got)
+	}
+	if _, err := in.GetVar("i"); err == nil {
+		t.Errorf("the variable of the for loop should not be global")
+	}
+	if out.String() != "30\n" {
+		t.Errorf("expected output %q, got %q", "30\n", out.String())
+	}
+}
+
+func TestStdoutStdin(t *testing.T) {
+	var out bytes.Buffer
+	in := New(WithStdout(&out), WithStdin(strings.NewReader("alice\r\nbob")))
+
+	mustRun(t, in, "print \"name?\"\nprint \"hi \" + input(), input(), len(input())")
+
+	expected := "name?\nhi alice bob 0\n"
+	if out.String() != expected {
+		t.Errorf("expected output %q, got %q", expected, out.String())
+	}
+}
+
+func TestWithBuiltin(t *testing.T) {
+	twice := &object.Builtin{Name: "twice", MinArgs: 1, MaxArgs: 1, Fn: func(_ object.CallContext, args ...object.Object) object.Object {
+		n, ok := args[0].(*object.Integer)
+		if !ok {
+			return object.ArgumentError("twice", 0, args[0], object.INTEGER_OBJ)
+		}
+		return &object.Integer{Value: 2 * n.Value}
+	}}
+	in, out := newTest(WithBuiltin(twice))
+
+	mustRun(t, in, "print twice(21)")
+	if out.String() != "42\n" {
+		t.Errorf("expected output %q, got %q", "42\n", out.String())
+	}
+
+	// only this interpreter has the builtin
+	other, _ := newTest()
+	var rtErr *RuntimeError
+	if err := other.Run(context.Background(), "twice(1)"); !errors.As(err, &rtErr) || rtErr.Kind != object.UNDEFINED_IDENT {
+		t.Errorf("expected an UNDEFINED_IDENT runtime error, got %v", err)
+	}
+}
+
+func TestSyntaxError(t *testing.T) {
+	in, out := newTest()
+	err := in.RunFile(context.Background(), "bad.cmm", "print 1\nx = 1 +\nprint 2 2")
+
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("expected a *SyntaxError, got %T: %v", err, err)
+	}
+	if len(syntaxErr.Errors) != 2 {
+		t.Fatalf("expected 2 syntax errors, got %d: %v", len(syntaxErr.Errors), syntaxErr)
+	}
+	first := syntaxErr.Errors[0]
+	if first.Pos.File != "bad.cmm" || first.Pos.Line != 2 {
+		t.Errorf("expected the first error on bad.cmm line 2, got %s", first.Pos)
+	}
+	expected := "bad.cmm:2:8: expected expression, found newline\nbad.cmm:3:9: expected newline, found \"2\""
+	if err.Error() != expected {
+		t.Errorf("expected error %q, got %q", expected, err.Error())
+	}
+
+	// a program with syntax errors is not run
+	if out.Len() != 0 {
+		t.Errorf("expected no output, got %q", out.String())
+	}
+}
+
+func TestRuntimeError(t *testing.T) {
+	in, out := newTest()
+	err := in.RunFile(context.Background(), "div.cmm", "print 1\nx = 1 / 0\nprint 2")
+
+	var rtErr *RuntimeError
+	if !errors.As(err, &rtErr) {
+		t.Fatalf("expected a *RuntimeError, got %T: %v", err, err)
+	}
+	if rtErr.Kind != object.ZERO_DIVISION || rtErr.Message != "division by zero" {
+		t.Errorf("expected a ZERO_DIVISION division by zero error, got %s %q", rtErr.Kind, rtErr.Message)
+	}
+	if rtErr.Pos.File != "div.cmm" || rtErr.Pos.Line != 2 || rtErr.Pos.Column != 7 {
+		t.Errorf("expected the error at div.cmm:2:7, got %s", rtErr.Pos)
+	}
+	if err.Error() != "div.cmm:2:7: runtime error: division by zero" {
+		t.Errorf("unexpected error text %q", err.Error())
+	}
+
+	// the output before the error is kept
+	if out.String() != "1\n" {
+		t.Errorf("expected output %q, got %q", "1\n", out.String())
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	in, _ := newTest()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := in.Run(ctx, "x = 0\nwhile (1) x = x + 1")
+
+	var rtErr *RuntimeError
+	if !errors.As(err, &rtErr) || rtErr.Kind != object.CANCELLED {
+		t.Fatalf("expected a CANCELLED runtime error, got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected errors.Is(err, context.DeadlineExceeded), got %v", err)
+	}
+
+	// the variables changed before the timeout are kept
+	if x, _ := in.GetVar("x"); x == int64(0) {
+		t.Errorf("expected the loop to have run, x is still 0")
+	}
+}
+
+func TestRunCancel(t *testing.T) {
+	in, _ := newTest()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	err := in.Run(ctx, "func step(n) { return n + 1 }\nx = 0\nwhile (1) x = step(x)")
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected errors.Is(err, context.Canceled), got %v", err)
+	}
+}
+
+func TestRunCancelledBefore(t *testing.T) {
+	in, out := newTest()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := in.RunFile(ctx, "late.cmm", "print 1\nwhile (1) {}")
+
+	var rtErr *RuntimeError
+	if !errors.As(err, &rtErr) || rtErr.Kind != object.CANCELLED {
+		t.Fatalf("expected a CANCELLED *RuntimeError, got %T: %v", err, err)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected errors.Is(err, context.Canceled), got %v", err)
+	}
+	if expected := "late.cmm:1:1: runtime error: execution cancelled"; err.Error() != expected {
+		t.Errorf("expected error %q, got %q", expected, err.Error())
+	}
+	// nothing of the program runs
+	if out.Len() != 0 {
+		t.Errorf("expected no output, got %q", out.String())
+	}
+}
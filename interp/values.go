@@ -0,0 +1,134 @@
+package interp
+
+import (
+	"fmt"
+	"toy_interpreter_go/lexer"
+	"toy_interpreter_go/object"
+)
+
+// SetVar : assign a Go value to a global variable of the programs
+// int, int64 and bool (1 or 0) become integers, string a string, []interface{} an array
+// and map[string]interface{} or map[int64]interface{} a hash of the converted values
+func (in *Interpreter) SetVar(name string, value interface{}) error {
+	tok := lexer.LexConstructor(name).NextToken()
+	if tok.Type != lexer.IDENT || tok.Val != name {
+		return fmt.Errorf("interp: %q is not an identifier", name)
+	}
+
+	obj, err := toObject(value)
+	if err != nil {
+		return fmt.Errorf("interp: variable %s: %w", name, err)
+	}
+	in.env.Set(name, obj)
+	return nil
+}
+
+// GetVar : the value of a global variable as a Go value
+// integers are int64, strings string, arrays []interface{} and hashes map[interface{}]interface{}
+// with int64 and string keys; a variable the programs never assigned, a function or an array
+// or hash that contains itself gives an error
+func (in *Interpreter) GetVar(name string) (interface{}, error) {
+	obj, ok := in.env.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("interp: variable %s: %w", name, ErrUndefined)
+	}
+
+	value, err := fromObject(obj, make(map[object.Object]bool))
+	if err != nil {
+		return nil, fmt.Errorf("interp: variable %s: %w", name, err)
+	}
+	return value, nil
+}
+
+func toObject(value interface{}) (object.Object, error) {
+	switch value := value.(type) {
+	case int:
+		return &object.Integer{Value: int64(value)}, nil
+	case int64:
+		return &object.Integer{Value: value}, nil
+	case bool:
+		if value {
+			return &object.Integer{Value: 1}, nil
+		}
+		return &object.Integer{Value: 0}, nil
+	case string:
+		return &object.String{Value: value}, nil
+	case []interface{}:
+		elements := make([]object.Object, len(value))
+		for i, v := range value {
+			element, err := toObject(v)
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = element
+		}
+		return &object.Array{Elements: elements}, nil
+	case map[string]interface{}:
+		hash := object.NewHash()
+		for k, v := range value {
+			val, err := toObject(v)
+			if err != nil {
+				return nil, err
+			}
+			hash.Set(&object.String{Value: k}, val)
+		}
+		return hash, nil
+	case map[int64]interface{}:
+		hash := object.NewHash()
+		for k, v := range value {
+			val, err := toObject(v)
+			if err != nil {
+				return nil, err
+			}
+			hash.Set(&object.Integer{Value: k}, val)
+		}
+		return hash, nil
+	default:
+		return nil, fmt.Errorf("unsupported Go type %T", value)
+	}
+}
+
+// open holds the arrays and hashes being converted around obj, to find the ones that contain themselves
+func fromObject(obj object.Object, open map[object.Object]bool) (interface{}, error) {
+	switch obj.(type) {
+	case *object.Array, *object.Hash:
+		if open[obj] {
+			return nil, fmt.Errorf("cannot convert %s that contains itself", obj.Type())
+		}
+		open[obj] = true
+		defer delete(open, obj)
+	}
+
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return obj.Value, nil
+	case *object.String:
+		return obj.Value, nil
+	case *object.Array:
+		values := make([]interface{}, len(obj.Elements))
+		for i, element := range obj.Elements {
+			value, err := fromObject(element, open)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		return values, nil
+	case *object.Hash:
+		values := make(map[interface{}]interface{}, len(obj.Pairs))
+		for _, pair := range obj.Pairs {
+			key, err := fromObject(pair.Key, open)
+			if err != nil {
+				return nil, err
+			}
+			value, err := fromObject(pair.Value, open)
+			if err != nil {
+				return nil, err
+			}
+			values[key] = value
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("cannot convert %s to a Go value", obj.Type())
+	}
+}
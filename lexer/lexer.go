@@ -1,23 +1,67 @@
 package lexer
 
-// import "regexp/syntax
-// import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // TokenType : the type of a token is string
 type TokenType string
 
+// Position : location of a character in the program
+type Position struct {
+	File   string // name of the source file, empty if unknown
+	Line   int    // line number, starting at 1
+	Column int    // column number in bytes, starting at 1
+	Offset int    // byte offset, starting at 0
+}
+
+// IsValid : reports whether the position points into a program
+func (p Position) IsValid() bool { return p.Line > 0 }
+
+// String : file:line:col, or line:col when the file is unknown
+func (p Position) String() string {
+	if !p.IsValid() {
+		return "-"
+	}
+	s := fmt.Sprintf("%d:%d", p.Line, p.Column)
+	if p.File != "" {
+		s = p.File + ":" + s
+	}
+	return s
+}
+
 // Token : token struct
 type Token struct {
-	Type TokenType // read program as string
-	Val  string    // holds the value of the token
+	Type     TokenType // read program as string
+	Val      string    // holds the value of the token
+	Pos      Position  // position of the first character of the token
+	End      Position  // position immediately after the last character of the token
+	Err      string    // for ILLEGAL tokens, why the input is not a token
+	Comments []Comment // comments between the previous token and this one
+}
+
+// Comment : a comment skipped by the lexer, kept so that a formatter can put it back
+type Comment struct {
+	Text string   // the whole comment including // or /* */
+	Pos  Position // position of the first character of the comment
+	End  Position // position immediately after the comment
 }
 
 // keywords table
 var key = map[string]TokenType{
-	"print": PRINT,
-	"if":    IF,
-	"else":  ELSE,
-	"while": WHILE,
+	"print":    PRINT,
+	"if":       IF,
+	"else":     ELSE,
+	"while":    WHILE,
+	"for":      FOR,
+	"do":       DO,
+	"break":    BREAK,
+	"continue": CONTINUE,
+	"func":     FUNC,
+	"fn":       FN,
+	"return":   RETURN,
+	"var":      VAR,
 }
 
 // keyLookup checks the keywords table and return either the keyword or identifier
@@ -28,6 +72,16 @@ func keyLookup(ident string) TokenType {
 	return IDENT
 }
 
+// Keyword : the reserved word of a keyword token type
+func Keyword(t TokenType) (string, bool) {
+	for word, tok := range key {
+		if tok == t {
+			return word, true
+		}
+	}
+	return "", false
+}
+
 // Tokens
 const (
 	// Punctuation and operators
@@ -35,12 +89,17 @@ const (
 	RPAR    = ")"
 	LBRAC   = "{"
 	RBRAC   = "}"
+	LSQUARE = "["
+	RSQUARE = "]"
 	COMMA   = ","
+	COLON   = ":"
+	SEMICOL = ";"
 	PLUS    = "+"
 	MINUS   = "-"
 	MULTIP  = "*"
 	DIVIDE  = "/"
 	MODULO  = "%"
+	NOT     = "!"
 	ASSIGN  = "="
 	EQUAL   = "=="
 	N_EQUAL = "!="
@@ -53,14 +112,23 @@ const (
 	NEWLINE = "\n"
 
 	// Identifier
-	IDENT = "IDENT"
-	NUM   = "NUM"
+	IDENT  = "IDENT"
+	NUM    = "NUM"
+	STRING = "STRING"
 
 	// Keywords
-	PRINT = "PRINT"
-	IF    = "IF"
-	ELSE  = "ELSE"
-	WHILE = "WHILE"
+	PRINT    = "PRINT"
+	IF       = "IF"
+	ELSE     = "ELSE"
+	WHILE    = "WHILE"
+	FOR      = "FOR"
+	DO       = "DO"
+	BREAK    = "BREAK"
+	CONTINUE = "CONTINUE"
+	FUNC     = "FUNC"
+	FN       = "FN"
+	RETURN   = "RETURN"
+	VAR      = "VAR"
 
 	// Other
 	ILLEGAL = "ILLEGAL"
@@ -70,20 +138,36 @@ const (
 // Lexer : lexer struct
 type Lexer struct {
 	input         string //program input
+	file          string // name of the program file, used in positions
 	char          byte   // current char under examination
 	position      int    // current position in input (points to current char)
 	positionIndex int    // current reading position in input (after current char)
+	line          int    // line of the current char
+	column        int    // column of the current char
 }
 
 // LexConstructor : constructor function of a lexer
 func LexConstructor(input string) *Lexer {
-	lex := &Lexer{input: input}
+	return LexFileConstructor("", input)
+}
+
+// LexFileConstructor : constructor function of a lexer for the contents of a named file
+func LexFileConstructor(file, input string) *Lexer {
+	lex := &Lexer{input: input, file: file, line: 1}
 	lex.scanChar()
 	return lex
 }
 
 // scanChar() gives us the next char and moves one step in the input string
 func (lex *Lexer) scanChar() {
+	// move the line and column past the char we leave behind
+	if lex.char == '\n' {
+		lex.line++
+		lex.column = 1
+	} else {
+		lex.column++
+	}
+
 	if lex.positionIndex >= len(lex.input) {
 		lex.char = 0 // 0 = NULL character in ASCII
 	} else {
@@ -93,13 +177,34 @@ func (lex *Lexer) scanChar() {
 	lex.positionIndex++
 }
 
+// pos returns the position of the current char
+func (lex *Lexer) pos() Position {
+	return Position{File: lex.file, Line: lex.line, Column: lex.column, Offset: lex.position}
+}
+
 // NextToken : read next token
+// the comments in front of the token are attached to it
 func (lex *Lexer) NextToken() Token {
+	comments, illegal := lex.skipComments()
+	if illegal != nil {
+		illegal.Comments = comments
+		return *illegal
+	}
+
+	tok := lex.scanToken()
+	tok.Comments = comments
+	return tok
+}
+
+// scanToken : read the token starting at the current char
+func (lex *Lexer) scanToken() Token {
 	var tok Token
 
 	// remove all spaces except newline characters
 	lex.spaceTrim()
 
+	start := lex.pos()
+
 	switch lex.char {
 
 	case '(':
@@ -110,8 +215,16 @@ func (lex *Lexer) NextToken() Token {
 		tok = newToken(LBRAC, lex.char)
 	case '}':
 		tok = newToken(RBRAC, lex.char)
+	case '[':
+		tok = newToken(LSQUARE, lex.char)
+	case ']':
+		tok = newToken(RSQUARE, lex.char)
 	case ',':
 		tok = newToken(COMMA, lex.char)
+	case ':':
+		tok = newToken(COLON, lex.char)
+	case ';':
+		tok = newToken(SEMICOL, lex.char)
 	case '+':
 		tok = newToken(PLUS, lex.char)
 	case '-':
@@ -161,7 +274,7 @@ func (lex *Lexer) NextToken() Token {
 			value := string(char) + string(lex.char)
 			tok = Token{Type: N_EQUAL, Val: value}
 		} else {
-			tok = newToken(ILLEGAL, lex.char)
+			tok = newToken(NOT, lex.char)
 		}
 
 	case '&':
@@ -184,18 +297,33 @@ func (lex *Lexer) NextToken() Token {
 			tok = newToken(ILLEGAL, lex.char)
 		}
 
+	case '"':
+		value, err := lex.readString()
+		tok.Pos, tok.End = start, lex.pos()
+		if err != "" {
+			tok.Type, tok.Val, tok.Err = ILLEGAL, lex.input[start.Offset:lex.position], err
+			return tok
+		}
+		tok.Type, tok.Val = STRING, value
+		return tok
+
 	case 0:
+		// stay at the end of the input, every later call returns EOF again
 		tok.Val = ""
 		tok.Type = EOF
+		tok.Pos, tok.End = start, start
+		return tok
 
 	default:
 		if 'a' <= lex.char && lex.char <= 'z' || 'A' <= lex.char && lex.char <= 'Z' {
 			tok.Val = lex.readIdentifier()
 			tok.Type = keyLookup(tok.Val)
+			tok.Pos, tok.End = start, lex.pos()
 			return tok
 		} else if '0' <= lex.char && lex.char <= '9' {
 			tok.Val = lex.readNumber()
 			tok.Type = NUM
+			tok.Pos, tok.End = start, lex.pos()
 			return tok
 		} else {
 			tok = newToken(ILLEGAL, lex.char)
@@ -203,13 +331,81 @@ func (lex *Lexer) NextToken() Token {
 	}
 
 	lex.scanChar()
+	tok.Pos, tok.End = start, lex.pos()
+	if tok.Type == ILLEGAL {
+		tok.Err = fmt.Sprintf("illegal character %q", tok.Val)
+	}
 	return tok
 }
 
+// skip spaces and comments, // comments end at the newline and /* */ comments do not nest
+// returns an ILLEGAL token at the start of a block comment that is not closed or contains /*
+func (lex *Lexer) skipComments() ([]Comment, *Token) {
+	var comments []Comment
+
+	for {
+		lex.spaceTrim()
+		if lex.char != '/' {
+			return comments, nil
+		}
+
+		start := lex.pos()
+		switch lex.lookAhead() {
+		case '/':
+			for lex.char != '\n' && lex.char != 0 {
+				lex.scanChar()
+			}
+		case '*':
+			if err := lex.skipBlockComment(); err != "" {
+				return comments, &Token{Type: ILLEGAL, Val: "/*", Pos: start, End: lex.pos(), Err: err}
+			}
+		default:
+			// division operator
+			return comments, nil
+		}
+
+		comments = append(comments, Comment{
+			Text: strings.TrimSuffix(lex.input[start.Offset:lex.position], "\r"), // of a \r\n line end
+			Pos:  start,
+			End:  lex.pos(),
+		})
+	}
+}
+
+// skip a /* */ comment, a nested /* is an error but its */ is matched
+// so that the lexer goes on after the whole comment
+func (lex *Lexer) skipBlockComment() string {
+	depth := 0
+	nested := false
+
+	for {
+		switch {
+		case lex.char == 0:
+			return "comment not terminated"
+		case lex.char == '/' && lex.lookAhead() == '*':
+			depth++
+			nested = nested || depth > 1
+			lex.scanChar()
+		case lex.char == '*' && lex.lookAhead() == '/':
+			depth--
+			lex.scanChar()
+			if depth == 0 {
+				lex.scanChar()
+				if nested {
+					return "comments cannot be nested"
+				}
+				return ""
+			}
+		}
+		lex.scanChar()
+	}
+}
+
 // ignore whitespace
 func (lex *Lexer) spaceTrim() {
 	// Don't ignore newline character - used to indentify the end of assignment
-	for lex.char == ' ' || lex.char == '\t' {
+	// a carriage return is skipped, so \r\n ends a line like \n
+	for lex.char == ' ' || lex.char == '\t' || lex.char == '\r' {
 		lex.scanChar()
 	}
 }
@@ -227,6 +423,47 @@ func (lex *Lexer) readIdentifier() string {
 	return lex.input[position:lex.position]
 }
 
+// read a string in double quotes, the current char is the opening quote
+// returns the value with the escape sequences \n \t \r \" and \\ replaced
+// a string must end on the line it starts
+func (lex *Lexer) readString() (string, string) {
+	var out strings.Builder
+	err := ""
+
+	for {
+		lex.scanChar()
+
+		switch lex.char {
+		case '"':
+			lex.scanChar()
+			return out.String(), err
+		case '\n', 0:
+			return out.String(), "string not terminated"
+		case '\\':
+			lex.scanChar()
+			switch lex.char {
+			case 'n':
+				out.WriteByte('\n')
+			case 't':
+				out.WriteByte('\t')
+			case 'r':
+				out.WriteByte('\r')
+			case '"', '\\':
+				out.WriteByte(lex.char)
+			case '\n', 0:
+				return out.String(), "string not terminated"
+			default:
+				// keep reading to report the error for the whole string
+				if err == "" {
+					err = fmt.Sprintf("unknown escape sequence \\%c", lex.char)
+				}
+			}
+		default:
+			out.WriteByte(lex.char)
+		}
+	}
+}
+
 // read numbers
 func (lex *Lexer) readNumber() string {
 	position := lex.position
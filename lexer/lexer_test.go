@@ -0,0 +1,30 @@
+package lexer
+
+import "testing"
+
+func TestCRLFLineEnds(t *testing.T) {
+	input := "x = 2 // two\r\nprint x + 1\r\n"
+	expected := []struct {
+		tokType TokenType
+		val     string
+		line    int
+	}{
+		{IDENT, "x", 1}, {ASSIGN, "=", 1}, {NUM, "2", 1}, {NEWLINE, "\n", 1},
+		{PRINT, "print", 2}, {IDENT, "x", 2}, {PLUS, "+", 2}, {NUM, "1", 2}, {NEWLINE, "\n", 2},
+		{EOF, "", 3},
+	}
+
+	lex := LexConstructor(input)
+	for i, want := range expected {
+		tok := lex.NextToken()
+		if tok.Type != want.tokType || tok.Val != want.val || tok.Pos.Line != want.line {
+			t.Fatalf("token %d: expected %s %q on line %d, got %s %q on line %d",
+				i, want.tokType, want.val, want.line, tok.Type, tok.Val, tok.Pos.Line)
+		}
+		if tok.Type == NEWLINE && want.line == 1 {
+			if len(tok.Comments) != 1 || tok.Comments[0].Text != "// two" {
+				t.Errorf("expected the comment \"// two\" before the first newline, got %v", tok.Comments)
+			}
+		}
+	}
+}
@@ -3,63 +3,53 @@ package main
 import (
 	"fmt"
 	"io"
-	"io/ioutil"
-	"log"
 	"os"
-	"toy_interpreter_go/evaluator"
-	"toy_interpreter_go/lexer"
-	"toy_interpreter_go/object"
-	"toy_interpreter_go/parser"
+	"toy_interpreter_go/repl"
 )
 
-func main() {
-	interpret("example1.cmm", "output1.txt")
-	interpret("example2.cmm", "output2.txt")
-	interpret("example3.cmm", "output3.txt")
-	interpret("example4.cmm", "output4.txt")
-	interpret("example5.cmm", "output5.txt")
-	interpret("example6.cmm", "output6.txt")
-}
-
-func interpret(src, dst string) (int, error) {
-	sourceFileStat, err := os.Stat(src)
-	if err != nil {
-		return 0, err
-	}
+// exit codes of the interpreter
+const (
+	exitOK      = 0 // every program ran to the end
+	exitFailure = 1 // bad usage or a file could not be read or written
+	exitSyntax  = 2 // a program has syntax errors
+	exitRuntime = 3 // a program stopped with a runtime error
+)
 
-	if !sourceFileStat.Mode().IsRegular() {
-		return 0, fmt.Errorf("%s is not a regular file", src)
-	}
+const usage = `Usage:
+  toy_interpreter_go run [-o file] [--timeout duration] [--dump-tokens] [--dump-ast] file...
+  toy_interpreter_go repl
+  toy_interpreter_go help
 
-	source, err := os.Open(src)
-	if err != nil {
-		return 0, err
-	}
+Commands:
+  run   run the programs in order, "-" reads a program from the standard input
+  repl  read and run statements interactively, :help lists the repl commands
+  help  print this message
 
-	content, err := ioutil.ReadFile(src)
-	if err != nil {
-		log.Fatal(err)
-	}
+Exit status: 0 on success, 1 on usage or i/o errors, 2 on syntax errors, 3 on runtime errors.
+`
 
-	code := string(content)
-
-	defer source.Close()
+func main() {
+	os.Exit(command(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
 
-	destination, err := os.Create(dst)
-	if err != nil {
-		return 0, err
+// command : run the command named by the first argument and return the exit code
+func command(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitFailure
 	}
 
-	defer destination.Close()
-
-	env := object.NewEnvironment()
-	lex := lexer.LexConstructor(code)
-	pars := parser.ParsConstructor(lex)
-	program := pars.ParseProgram()
-	fmt.Println("Parsed program: \n", program.Statements)
-	evaluated := evaluator.Eval(program, env)
-
-	nBytes, err := io.WriteString(destination, evaluated.Inspect())
-
-	return nBytes, err
+	switch args[0] {
+	case "run":
+		return runCommand(args[1:], stdin, stdout, stderr)
+	case "repl":
+		repl.Start(stdin, stdout)
+		return exitOK
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return exitOK
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+		return exitFailure
+	}
 }
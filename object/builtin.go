@@ -0,0 +1,61 @@
+package object
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// VARIADIC : MaxArgs of a builtin that takes any number of arguments from MinArgs on
+const VARIADIC = -1
+
+// BuiltinFunction : the Go function behind a builtin, it is only called with a number
+// of arguments the builtin accepts; an *Error it returns without a position is reported at the call
+type BuiltinFunction func(call CallContext, args ...Object) Object
+
+// CallContext : what a builtin can use of the program that calls it
+type CallContext interface {
+	// Context : the context of the running program, a slow builtin should stop once it is done
+	Context() context.Context
+	// Apply : call a function or builtin of the program with args, as the program would at the
+	// call of the builtin; nil if it returns no value, an *Error to return as it is if it failed
+	Apply(fn Object, args ...Object) Object
+}
+
+// Builtin : a function of the interpreter written in Go
+type Builtin struct {
+	Name    string
+	MinArgs int
+	MaxArgs int // VARIADIC for no limit
+	Fn      BuiltinFunction
+}
+
+func (b *Builtin) Type() ObjectType { return BUILTIN_OBJ }
+func (b *Builtin) Inspect() string  { return "builtin " + b.Name }
+
+// Accepts : reports whether the builtin can be called with n arguments
+func (b *Builtin) Accepts(n int) bool {
+	return n >= b.MinArgs && (b.MaxArgs == VARIADIC || n <= b.MaxArgs)
+}
+
+// Arity : the number of arguments the builtin takes, as in "1", "2 to 3" or "at least 1"
+func (b *Builtin) Arity() string {
+	switch {
+	case b.MaxArgs == VARIADIC:
+		return fmt.Sprintf("at least %d", b.MinArgs)
+	case b.MinArgs == b.MaxArgs:
+		return fmt.Sprintf("%d", b.MinArgs)
+	default:
+		return fmt.Sprintf("%d to %d", b.MinArgs, b.MaxArgs)
+	}
+}
+
+// ArgumentError : error for the argument i, counting from 0, of a builtin that is none of the types want
+func ArgumentError(name string, i int, arg Object, want ...ObjectType) *Error {
+	types := make([]string, len(want))
+	for j, t := range want {
+		types[j] = string(t)
+	}
+	return NewError(TYPE_MISMATCH, "argument %d of %s must be %s, got %s",
+		i+1, name, strings.Join(types, " or "), arg.Type())
+}
@@ -1,23 +1,86 @@
 package object
 
+import "sort"
+
 // the Environment is a hash map that associates strings with objects.
 // to keep track of the values of the identifiers and actually bind a value to a name
+// environments are chained: a function call gets an environment enclosed by the one
+// of the function declaration, and every block gets one enclosed by the surrounding code
 
 func NewEnvironment() *Environment {
 	s := make(map[string]Object)
 	return &Environment{store: s}
 }
 
+// NewEnclosedEnvironment : environment of a function call, names not found are looked up in outer
+func NewEnclosedEnvironment(outer *Environment) *Environment {
+	env := NewEnvironment()
+	env.outer = outer
+	return env
+}
+
+// NewBlockEnvironment : environment of a block, it only holds the names declared with var
+// in the block, assignments to undeclared names go to the enclosing function or program
+func NewBlockEnvironment(outer *Environment) *Environment {
+	// the store is created by the first declaration, most blocks declare nothing
+	return &Environment{outer: outer, block: true}
+}
+
 type Environment struct {
 	store map[string]Object
+	outer *Environment
+	block bool // scope of a block rather than of a function call or the program
 }
 
 func (e *Environment) Get(name string) (Object, bool) {
 	obj, ok := e.store[name]
+	if !ok && e.outer != nil {
+		obj, ok = e.outer.Get(name)
+	}
 	return obj, ok
 }
 
+// Set : bind the name in this environment
 func (e *Environment) Set(name string, val Object) Object {
+	if e.store == nil {
+		e.store = make(map[string]Object)
+	}
 	e.store[name] = val
 	return val
 }
+
+// Declare : bind a new name in this environment, shadowing the outer ones
+// returns false if the name is already declared in this environment
+func (e *Environment) Declare(name string, val Object) bool {
+	if _, ok := e.store[name]; ok {
+		return false
+	}
+	e.Set(name, val)
+	return true
+}
+
+// Assign : update the closest environment that binds the name, a name bound
+// nowhere becomes a variable of the enclosing function call or of the program
+func (e *Environment) Assign(name string, val Object) Object {
+	for env := e; env != nil; env = env.outer {
+		if _, ok := env.store[name]; ok {
+			return env.Set(name, val)
+		}
+	}
+
+	env := e
+	for env.block && env.outer != nil {
+		env = env.outer
+	}
+	return env.Set(name, val)
+}
+
+// Names : the names bound in this environment in alphabetical order
+func (e *Environment) Names() []string {
+	names := make([]string, 0, len(e.store))
+	for name := range e.store {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
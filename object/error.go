@@ -0,0 +1,45 @@
+package object
+
+import (
+	"fmt"
+	"toy_interpreter_go/lexer"
+)
+
+// ErrorKind : category of a runtime error
+type ErrorKind string
+
+// Runtime error kinds
+const (
+	ZERO_DIVISION    = "ZERO_DIVISION"    // division or modulo by zero
+	UNDEFINED_IDENT  = "UNDEFINED_IDENT"  // identifier used before it was assigned
+	TYPE_MISMATCH    = "TYPE_MISMATCH"    // operands of the wrong type
+	UNKNOWN_OPERATOR = "UNKNOWN_OPERATOR" // operator not defined for its operands
+	NO_VALUE         = "NO_VALUE"         // expression without a value used as a value
+	NOT_A_FUNCTION   = "NOT_A_FUNCTION"   // call of a value that is not a function
+	WRONG_ARGUMENTS  = "WRONG_ARGUMENTS"  // call with the wrong number of arguments
+	CALL_DEPTH       = "CALL_DEPTH"       // too many nested calls, usually runaway recursion
+	REDECLARED       = "REDECLARED"       // var of a name already declared in the same scope
+	INDEX_RANGE      = "INDEX_RANGE"      // index outside of an array or string
+	KEY_NOT_FOUND    = "KEY_NOT_FOUND"    // hash read with a key it does not have
+	IO_ERROR         = "IO_ERROR"         // reading the input of the program failed
+	CANCELLED        = "CANCELLED"        // the context of the evaluation was cancelled or timed out
+)
+
+// Error : runtime error, stops the evaluation of the program
+type Error struct {
+	Kind    ErrorKind
+	Message string
+	Pos     lexer.Position // where in the program the error happened
+	Err     error          // the Go error that caused it, if any, like context.DeadlineExceeded
+}
+
+func (e *Error) Type() ObjectType { return ERROR_OBJ }
+func (e *Error) Inspect() string  { return "runtime error: " + e.Message }
+
+// NewError : runtime error without a position, for builtins, the call gives it its position
+func NewError(kind ErrorKind, format string, args ...interface{}) *Error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+// Error : the error in file:line:col: runtime error: message form
+func (e *Error) Error() string { return e.Pos.String() + ": " + e.Inspect() }
@@ -0,0 +1,95 @@
+package object
+
+import (
+	"sort"
+	"strings"
+)
+
+// HashKey : the value of a key inside a Hash, equal keys have equal HashKeys
+type HashKey struct {
+	Type ObjectType
+	Int  int64
+	Str  string
+}
+
+// Hashable : implemented by the objects that can be keys of a Hash
+type Hashable interface {
+	Object
+	HashKey() HashKey
+}
+
+func (i *Integer) HashKey() HashKey { return HashKey{Type: INTEGER_OBJ, Int: i.Value} }
+func (s *String) HashKey() HashKey  { return HashKey{Type: STRING_OBJ, Str: s.Value} }
+
+// HashPair : a key with its value, the key is kept to list the keys of the Hash
+type HashPair struct {
+	Key   Hashable
+	Value Object
+}
+
+// Hash : a map from integers and strings to values, shared by every variable that holds it
+type Hash struct {
+	Pairs map[HashKey]HashPair
+}
+
+// NewHash : an empty hash
+func NewHash() *Hash {
+	return &Hash{Pairs: make(map[HashKey]HashPair)}
+}
+
+func (h *Hash) Type() ObjectType { return HASH_OBJ }
+func (h *Hash) Inspect() string  { return h.inspect(make(map[Object]bool)) }
+
+// a hash that contains itself is printed as {...} where it repeats, like arrays
+func (h *Hash) inspect(open map[Object]bool) string {
+	if open[h] {
+		return "{...}"
+	}
+	open[h] = true
+	defer delete(open, h)
+
+	pairs := h.Sorted()
+	elements := make([]string, len(pairs))
+	for i, pair := range pairs {
+		elements[i] = inspectElement(pair.Key, open) + ": " + inspectElement(pair.Value, open)
+	}
+	return "{" + strings.Join(elements, ", ") + "}"
+}
+
+// Get : the value of key
+func (h *Hash) Get(key Hashable) (Object, bool) {
+	pair, ok := h.Pairs[key.HashKey()]
+	return pair.Value, ok
+}
+
+// Set : bind key to value, replacing the old value
+func (h *Hash) Set(key Hashable, value Object) {
+	h.Pairs[key.HashKey()] = HashPair{Key: key, Value: value}
+}
+
+// Delete : remove key, reports whether it was there
+func (h *Hash) Delete(key Hashable) bool {
+	_, ok := h.Pairs[key.HashKey()]
+	delete(h.Pairs, key.HashKey())
+	return ok
+}
+
+// Sorted : the pairs with the integer keys first in increasing order, then the string keys
+// in byte order, so that printing a hash and listing its keys give the same order every time
+func (h *Hash) Sorted() []HashPair {
+	pairs := make([]HashPair, 0, len(h.Pairs))
+	for _, pair := range h.Pairs {
+		pairs = append(pairs, pair)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		a, b := pairs[i].Key.HashKey(), pairs[j].Key.HashKey()
+		if a.Type != b.Type {
+			return a.Type == INTEGER_OBJ
+		}
+		if a.Type == INTEGER_OBJ {
+			return a.Int < b.Int
+		}
+		return a.Str < b.Str
+	})
+	return pairs
+}
@@ -1,14 +1,27 @@
 package object
 
 import (
+	"bytes"
 	"fmt"
+	"strconv"
+	"strings"
+	"toy_interpreter_go/tree"
 )
 
 type ObjectType string
 
 const (
-	INTEGER_OBJ     = "INTEGER"
-	PRINT_VALUE_OBJ = "PRINT_VALUE"
+	INTEGER_OBJ  = "INTEGER"
+	STRING_OBJ   = "STRING"
+	ERROR_OBJ    = "ERROR"
+	BREAK_OBJ    = "BREAK"
+	CONTINUE_OBJ = "CONTINUE"
+
+	ARRAY_OBJ        = "ARRAY"
+	HASH_OBJ         = "HASH"
+	FUNCTION_OBJ     = "FUNCTION"
+	BUILTIN_OBJ      = "BUILTIN"
+	RETURN_VALUE_OBJ = "RETURN_VALUE"
 )
 
 type Object interface {
@@ -24,10 +37,103 @@ type Integer struct {
 func (i *Integer) Type() ObjectType { return INTEGER_OBJ }
 func (i *Integer) Inspect() string  { return fmt.Sprintf("%d", i.Value) }
 
-// PrintValue : Print value object
-type PrintValue struct {
-	Value Object
+// String : a sequence of bytes, printed without quotes
+type String struct {
+	Value string
+}
+
+func (s *String) Type() ObjectType { return STRING_OBJ }
+func (s *String) Inspect() string  { return s.Value }
+
+// Array : a list of values, shared by every variable that holds it
+type Array struct {
+	Elements []Object
+}
+
+func (a *Array) Type() ObjectType { return ARRAY_OBJ }
+func (a *Array) Inspect() string  { return a.inspect(make(map[Object]bool)) }
+
+// open holds the arrays and hashes being printed around this one, an array that
+// contains itself is printed as [...] where it repeats
+func (a *Array) inspect(open map[Object]bool) string {
+	if open[a] {
+		return "[...]"
+	}
+	open[a] = true
+	defer delete(open, a)
+
+	elements := make([]string, len(a.Elements))
+	for i, e := range a.Elements {
+		elements[i] = inspectElement(e, open)
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// strings inside an array or a hash are quoted, so ["a, b"] and ["a", "b"] print differently
+func inspectElement(obj Object, open map[Object]bool) string {
+	switch obj := obj.(type) {
+	case *String:
+		return strconv.Quote(obj.Value)
+	case *Array:
+		return obj.inspect(open)
+	case *Hash:
+		return obj.inspect(open)
+	default:
+		return obj.Inspect()
+	}
+}
+
+// Function : a function with the environment it was created in, which it keeps alive
+type Function struct {
+	Name       string // empty for a function literal
+	Parameters []*tree.Identifier
+	Body       *tree.BlockStatement
+	Env        *Environment
 }
 
-func (pv *PrintValue) Type() ObjectType { return PRINT_VALUE_OBJ }
-func (pv *PrintValue) Inspect() string  { return pv.Value.Inspect() }
+func (f *Function) Type() ObjectType { return FUNCTION_OBJ }
+func (f *Function) Inspect() string {
+	var out bytes.Buffer
+
+	params := make([]string, len(f.Parameters))
+	for i, p := range f.Parameters {
+		params[i] = p.String()
+	}
+
+	if f.Name == "" {
+		out.WriteString("fn")
+	} else {
+		out.WriteString("func ")
+		out.WriteString(f.Name)
+	}
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(")")
+
+	return out.String()
+}
+
+// ReturnValue : signal of a return statement, unwinds to the function call
+type ReturnValue struct {
+	Value Object // nil for a bare return
+}
+
+func (rv *ReturnValue) Type() ObjectType { return RETURN_VALUE_OBJ }
+func (rv *ReturnValue) Inspect() string {
+	if rv.Value == nil {
+		return "return"
+	}
+	return rv.Value.Inspect()
+}
+
+// Break : signal of a break statement, unwinds to the innermost loop
+type Break struct{}
+
+func (b *Break) Type() ObjectType { return BREAK_OBJ }
+func (b *Break) Inspect() string  { return "break" }
+
+// Continue : signal of a continue statement, unwinds to the innermost loop
+type Continue struct{}
+
+func (c *Continue) Type() ObjectType { return CONTINUE_OBJ }
+func (c *Continue) Inspect() string  { return "continue" }
@@ -0,0 +1,37 @@
+package parser
+
+import (
+	"toy_interpreter_go/lexer"
+	"toy_interpreter_go/tree"
+)
+
+// [a, b, c], the current token is the [
+func (pars *Parser) parseArrayLiteral() tree.Expression {
+	array := &tree.ArrayLiteral{Token: pars.thisToken}
+
+	array.Elements = pars.parseExpressionList(lexer.RSQUARE)
+	if array.Elements == nil {
+		return nil
+	}
+	array.EndToken = pars.thisToken
+
+	return array
+}
+
+// left[index], the current token is the [
+func (pars *Parser) parseIndexExpression(left tree.Expression) tree.Expression {
+	expression := &tree.IndexExpression{Token: pars.thisToken, Left: left}
+
+	pars.nextToken()
+	expression.Index = pars.parseExpression(LOWEST)
+	if expression.Index == nil {
+		return nil
+	}
+
+	if !pars.expectPeek(lexer.RSQUARE) {
+		return nil
+	}
+	expression.EndToken = pars.thisToken
+
+	return expression
+}
@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"toy_interpreter_go/lexer"
+)
+
+// ParseError : a syntax error found while parsing a program
+type ParseError struct {
+	Pos      lexer.Position    // where the error was found
+	Expected []lexer.TokenType // token types that would have been accepted, if known
+	Actual   lexer.Token       // the token found instead
+	Msg      string            // description of the error
+}
+
+// Error : the error in file:line:col: message form
+func (pe *ParseError) Error() string {
+	return pe.Pos.String() + ": " + pe.Msg
+}
+
+// Errors : the syntax errors found by ParseProgram, in source order
+func (pars *Parser) Errors() []*ParseError {
+	return pars.errors
+}
+
+// record an error at the given token
+// only the first error of a statement is recorded, the rest are usually caused by it
+func (pars *Parser) errorAt(tok lexer.Token, expected []lexer.TokenType, format string, args ...interface{}) {
+	if pars.panicMode {
+		return
+	}
+	pars.panicMode = true
+	pars.errors = append(pars.errors, &ParseError{
+		Pos:      tok.Pos,
+		Expected: expected,
+		Actual:   tok,
+		Msg:      fmt.Sprintf(format, args...),
+	})
+}
+
+// the next token is not one of the expected types
+func (pars *Parser) peekError(expected ...lexer.TokenType) {
+	if pars.peekTokenIs(lexer.ILLEGAL) {
+		pars.errorAt(pars.peekToken, expected, "%s", pars.peekToken.Err)
+		return
+	}
+
+	names := make([]string, len(expected))
+	for i, t := range expected {
+		names[i] = describeType(t)
+	}
+	pars.errorAt(pars.peekToken, expected, "expected %s, found %s",
+		strings.Join(names, " or "), describeToken(pars.peekToken))
+}
+
+// no expression can start with the current token
+func (pars *Parser) noPrefixParseFnError() {
+	if pars.curTokenIs(lexer.ILLEGAL) {
+		pars.errorAt(pars.thisToken, nil, "%s", pars.thisToken.Err)
+		return
+	}
+	pars.errorAt(pars.thisToken, nil, "expected expression, found %s", describeToken(pars.thisToken))
+}
+
+// human readable name of a token type
+func describeType(t lexer.TokenType) string {
+	switch t {
+	case lexer.NEWLINE:
+		return "newline"
+	case lexer.EOF:
+		return "end of file"
+	case lexer.IDENT:
+		return "identifier"
+	case lexer.NUM:
+		return "number"
+	case lexer.STRING:
+		return "string"
+	}
+	if keyword, ok := lexer.Keyword(t); ok {
+		return fmt.Sprintf("%q", keyword)
+	}
+	return fmt.Sprintf("%q", string(t))
+}
+
+// human readable description of a token
+func describeToken(tok lexer.Token) string {
+	switch tok.Type {
+	case lexer.NEWLINE, lexer.EOF:
+		return describeType(tok.Type)
+	}
+	return fmt.Sprintf("%q", tok.Val)
+}
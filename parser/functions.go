@@ -0,0 +1,161 @@
+package parser
+
+import (
+	"toy_interpreter_go/lexer"
+	"toy_interpreter_go/tree"
+)
+
+// func name(a, b) { body }
+func (pars *Parser) parseFunctionStatement() tree.Statement {
+	stmt := &tree.FunctionStatement{Token: pars.thisToken}
+
+	if !pars.expectPeek(lexer.IDENT) {
+		return nil
+	}
+	stmt.Name = &tree.Identifier{Token: pars.thisToken, Value: pars.thisToken.Val}
+
+	if !pars.expectPeek(lexer.LPAR) {
+		return nil
+	}
+	stmt.Parameters = pars.parseFunctionParameters()
+	if stmt.Parameters == nil {
+		return nil
+	}
+
+	stmt.Body = pars.parseFunctionBody()
+	if stmt.Body == nil {
+		return nil
+	}
+
+	pars.endStatement()
+	return stmt
+}
+
+// fn(a, b) { body }, an expression
+func (pars *Parser) parseFunctionLiteral() tree.Expression {
+	literal := &tree.FunctionLiteral{Token: pars.thisToken}
+
+	if !pars.expectPeek(lexer.LPAR) {
+		return nil
+	}
+	literal.Parameters = pars.parseFunctionParameters()
+	if literal.Parameters == nil {
+		return nil
+	}
+
+	literal.Body = pars.parseFunctionBody()
+	if literal.Body == nil {
+		return nil
+	}
+
+	return literal
+}
+
+// parameter names up to the ), the current token is the (
+func (pars *Parser) parseFunctionParameters() []*tree.Identifier {
+	identifiers := []*tree.Identifier{}
+
+	if pars.peekTokenIs(lexer.RPAR) {
+		pars.nextToken()
+		return identifiers
+	}
+
+	for {
+		if !pars.expectPeek(lexer.IDENT) {
+			return nil
+		}
+		identifiers = append(identifiers, &tree.Identifier{Token: pars.thisToken, Value: pars.thisToken.Val})
+
+		if !pars.peekTokenIs(lexer.COMMA) {
+			break
+		}
+		pars.nextToken()
+	}
+
+	if !pars.expectPeek(lexer.RPAR) {
+		return nil
+	}
+	return identifiers
+}
+
+// the body of a function is always a block, which may start on the next line
+// break and continue cannot reach the loops around the declaration
+func (pars *Parser) parseFunctionBody() *tree.BlockStatement {
+	pars.skipNewlines()
+	if !pars.expectPeek(lexer.LBRAC) {
+		return nil
+	}
+
+	outerLoopDepth := pars.loopDepth
+	pars.loopDepth = 0
+	pars.funcDepth++
+
+	body := pars.parseBlockStatement()
+
+	pars.funcDepth--
+	pars.loopDepth = outerLoopDepth
+
+	return body
+}
+
+// return, or return expression, only allowed inside a function
+func (pars *Parser) parseReturnStatement() tree.Statement {
+	if pars.funcDepth == 0 {
+		pars.errorAt(pars.thisToken, nil, "return outside of a function")
+		return nil
+	}
+
+	stmt := &tree.ReturnStatement{Token: pars.thisToken}
+
+	switch {
+	case pars.peekTokenIs(lexer.NEWLINE), pars.peekTokenIs(lexer.RBRAC), pars.peekTokenIs(lexer.EOF):
+	case pars.peekTokenIsFollow():
+	default:
+		pars.nextToken()
+		stmt.Value = pars.parseExpression(LOWEST)
+	}
+
+	pars.endStatement()
+	return stmt
+}
+
+// function(arguments), the current token is the (
+func (pars *Parser) parseCallExpression(function tree.Expression) tree.Expression {
+	expression := &tree.CallExpression{Token: pars.thisToken, Function: function}
+
+	expression.Arguments = pars.parseExpressionList(lexer.RPAR)
+	if expression.Arguments == nil {
+		return nil
+	}
+	expression.EndToken = pars.thisToken
+
+	return expression
+}
+
+// comma separated expressions up to the end token, the current token is the ( or [
+// like the pairs of a hash literal, the list may be written on several lines
+func (pars *Parser) parseExpressionList(end lexer.TokenType) []tree.Expression {
+	list := []tree.Expression{}
+
+	pars.skipNewlines()
+	for !pars.peekTokenIs(end) {
+		pars.nextToken()
+		exp := pars.parseExpression(LOWEST)
+		if exp == nil {
+			return nil
+		}
+		list = append(list, exp)
+
+		pars.skipNewlines()
+		if !pars.peekTokenIs(lexer.COMMA) {
+			break
+		}
+		pars.nextToken()
+		pars.skipNewlines()
+	}
+
+	if !pars.expectPeek(end) {
+		return nil
+	}
+	return list
+}
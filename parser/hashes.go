@@ -0,0 +1,42 @@
+package parser
+
+import (
+	"toy_interpreter_go/lexer"
+	"toy_interpreter_go/tree"
+)
+
+// {key: value, ...}, the current token is the {
+// the pairs may be written on several lines
+func (pars *Parser) parseHashLiteral() tree.Expression {
+	hash := &tree.HashLiteral{Token: pars.thisToken, Pairs: []tree.KeyValue{}}
+
+	pars.skipNewlines()
+	for !pars.peekTokenIs(lexer.RBRAC) {
+		pars.nextToken()
+		key := pars.parseExpression(LOWEST)
+		if key == nil || !pars.expectPeek(lexer.COLON) {
+			return nil
+		}
+
+		pars.nextToken()
+		value := pars.parseExpression(LOWEST)
+		if value == nil {
+			return nil
+		}
+		hash.Pairs = append(hash.Pairs, tree.KeyValue{Key: key, Value: value})
+
+		pars.skipNewlines()
+		if !pars.peekTokenIs(lexer.COMMA) {
+			break
+		}
+		pars.nextToken()
+		pars.skipNewlines()
+	}
+
+	if !pars.expectPeek(lexer.RBRAC) {
+		return nil
+	}
+	hash.EndToken = pars.thisToken
+
+	return hash
+}
@@ -0,0 +1,71 @@
+package parser
+
+import "toy_interpreter_go/lexer"
+
+// for precedence, from the loosest to the tightest binding
+const (
+	_ int = iota // succesive integers, ignore first value by assigning to blank
+	LOWEST
+	LOGICALOR  // ||
+	LOGICALAND // &&
+	EQUALS     // == !=
+	LESSMORE   // < > <= >=
+	SUM        // + -
+	PRODUCT    // * / %
+	PREFIX     // unary - + !
+	CALL       // f(x)
+	INDEX      // a[i]
+)
+
+// Associativity : how a chain of operators of the same precedence is grouped
+type Associativity int
+
+const (
+	LEFT_ASSOC  Associativity = iota // a - b - c is (a - b) - c
+	RIGHT_ASSOC                      // a = b = c is a = (b = c)
+)
+
+// Operator : entry of the binary operator table
+type Operator struct {
+	Token         lexer.TokenType
+	Precedence    int
+	Associativity Associativity
+}
+
+// Operators : the binary operators with the precedence and associativity they have in C
+// the parser registers an infix parse function for every entry
+var Operators = []Operator{
+	{lexer.OR, LOGICALOR, LEFT_ASSOC},
+	{lexer.AND, LOGICALAND, LEFT_ASSOC},
+	{lexer.EQUAL, EQUALS, LEFT_ASSOC},
+	{lexer.N_EQUAL, EQUALS, LEFT_ASSOC},
+	{lexer.LESS, LESSMORE, LEFT_ASSOC},
+	{lexer.MORE, LESSMORE, LEFT_ASSOC},
+	{lexer.LESS_EQ, LESSMORE, LEFT_ASSOC},
+	{lexer.MORE_EQ, LESSMORE, LEFT_ASSOC},
+	{lexer.PLUS, SUM, LEFT_ASSOC},
+	{lexer.MINUS, SUM, LEFT_ASSOC},
+	{lexer.MULTIP, PRODUCT, LEFT_ASSOC},
+	{lexer.DIVIDE, PRODUCT, LEFT_ASSOC},
+	{lexer.MODULO, PRODUCT, LEFT_ASSOC},
+}
+
+// operator table indexed by token type
+var operators = func() map[lexer.TokenType]Operator {
+	table := make(map[lexer.TokenType]Operator, len(Operators))
+	for _, op := range Operators {
+		table[op.Token] = op
+	}
+	return table
+}()
+
+// precedence of every token that continues an expression
+var precedences = func() map[lexer.TokenType]int {
+	table := make(map[lexer.TokenType]int, len(Operators))
+	for _, op := range Operators {
+		table[op.Token] = op.Precedence
+	}
+	table[lexer.LPAR] = CALL
+	table[lexer.LSQUARE] = INDEX
+	return table
+}()
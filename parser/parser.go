@@ -1,41 +1,11 @@
 package parser
 
 import (
+	"strconv"
 	"toy_interpreter_go/lexer"
 	"toy_interpreter_go/tree"
-	"strconv"
-)
-
-// for precedence
-const (
-	_ int = iota // succesive integers, ignore first value by assigning to blank
-	LOWEST
-	LOGICALAND // &&
-	LOGICALOR  // ||
-	EQUALS     // ==
-	LESSMORE   // > or <
-	SUM        // + -
-	PRODUCT    // * / %
-	PREFIX     // to handle (
 )
 
-var precedences = map[lexer.TokenType]int{
-	// precedences as in C language
-	lexer.AND:     LOGICALAND,
-	lexer.OR:      LOGICALOR,
-	lexer.EQUAL:   EQUALS,
-	lexer.N_EQUAL: EQUALS,
-	lexer.LESS:    LESSMORE,
-	lexer.MORE:    LESSMORE,
-	lexer.MORE_EQ: LESSMORE,
-	lexer.LESS_EQ: LESSMORE,
-	lexer.PLUS:    SUM,
-	lexer.MINUS:   SUM,
-	lexer.DIVIDE:  PRODUCT,
-	lexer.MULTIP:  PRODUCT,
-	lexer.MODULO:  PRODUCT,
-}
-
 // Parser : Parse struct
 type Parser struct {
 	lex       *lexer.Lexer
@@ -44,6 +14,18 @@ type Parser struct {
 
 	prefixParseFns map[lexer.TokenType]prefixParseFn
 	infixParseFns  map[lexer.TokenType]infixParseFn
+
+	errors    []*ParseError
+	panicMode bool // an error was reported and the parser did not yet synchronize
+
+	loopDepth int               // number of loops around the current statement, reset in function bodies
+	funcDepth int               // number of function declarations around the current statement
+	follow    []lexer.TokenType // tokens that may end the single statement bodies being parsed, else and while
+
+	braces      int // number of { before the current token that are not closed yet
+	parens      int // number of ( before the current token that are not closed yet
+	blockBraces int // braces inside the innermost block being parsed, more are nested blocks or hash literals
+	blockParens int // parens inside the innermost block being parsed, newlines inside more of them are dropped
 }
 
 // Parsing functions,
@@ -59,25 +41,23 @@ func ParsConstructor(lex *lexer.Lexer) *Parser {
 	pars.prefixParseFns = make(map[lexer.TokenType]prefixParseFn)
 	pars.infixParseFns = make(map[lexer.TokenType]infixParseFn)
 
-	pars.registerInfix(lexer.PLUS, pars.parseInfixExpression)
-	pars.registerInfix(lexer.MINUS, pars.parseInfixExpression)
-	pars.registerInfix(lexer.DIVIDE, pars.parseInfixExpression)
-	pars.registerInfix(lexer.MULTIP, pars.parseInfixExpression)
-	pars.registerInfix(lexer.MODULO, pars.parseInfixExpression)
-	pars.registerInfix(lexer.EQUAL, pars.parseInfixExpression)
-	pars.registerInfix(lexer.N_EQUAL, pars.parseInfixExpression)
-	pars.registerInfix(lexer.LESS, pars.parseInfixExpression)
-	pars.registerInfix(lexer.LESS_EQ, pars.parseInfixExpression)
-	pars.registerInfix(lexer.MORE, pars.parseInfixExpression)
-	pars.registerInfix(lexer.MORE_EQ, pars.parseInfixExpression)
-	pars.registerInfix(lexer.OR, pars.parseInfixExpression)
-	pars.registerInfix(lexer.AND, pars.parseInfixExpression)
+	for _, op := range Operators {
+		pars.registerInfix(op.Token, pars.parseInfixExpression)
+	}
+	pars.registerInfix(lexer.LPAR, pars.parseCallExpression)
+	pars.registerInfix(lexer.LSQUARE, pars.parseIndexExpression)
 
 	pars.registerPrefix(lexer.IDENT, pars.parseIdentifier)
 	pars.registerPrefix(lexer.NUM, pars.parseIntegerLiteral)
+	pars.registerPrefix(lexer.STRING, pars.parseStringLiteral)
 	pars.registerPrefix(lexer.LPAR, pars.parseGroupedExpression)
-	pars.registerPrefix(lexer.IF, pars.parseIfExpression)
-	pars.registerPrefix(lexer.WHILE, pars.parseWhileExpression)
+	pars.registerPrefix(lexer.MINUS, pars.parsePrefixExpression)
+	pars.registerPrefix(lexer.PLUS, pars.parsePrefixExpression)
+	pars.registerPrefix(lexer.NOT, pars.parsePrefixExpression)
+	pars.registerPrefix(lexer.FN, pars.parseFunctionLiteral)
+	pars.registerPrefix(lexer.LSQUARE, pars.parseArrayLiteral)
+	// a { starting a statement is a block, parseStatement never reaches this
+	pars.registerPrefix(lexer.LBRAC, pars.parseHashLiteral)
 
 	// read two tokens, one for thisToken and one for peekToken
 	pars.nextToken()
@@ -98,15 +78,39 @@ func (pars *Parser) registerInfix(tokenType lexer.TokenType, fn infixParseFn) {
 func (pars *Parser) nextToken() {
 	pars.thisToken = pars.peekToken
 	pars.peekToken = pars.lex.NextToken()
+
+	switch pars.thisToken.Type {
+	case lexer.LBRAC:
+		pars.braces++
+	case lexer.RBRAC:
+		pars.braces--
+	case lexer.LPAR:
+		pars.parens++
+	case lexer.RPAR:
+		// a ) of a statement abandoned by recovery does not close a ( of the code around it
+		if pars.parens > pars.blockParens {
+			pars.parens--
+		}
+	}
+	pars.dropNewlines()
+}
+
+// newlines inside ( ) do not end anything, so that a condition, a grouped expression or
+// the arguments of a call may go on over several lines; a block inside the ( ) has its own lines
+func (pars *Parser) dropNewlines() {
+	for pars.parens > pars.blockParens && pars.peekTokenIs(lexer.NEWLINE) {
+		pars.peekToken = pars.lex.NextToken()
+	}
 }
 
 // ParseProgram : Parse the program statement until EOF is encountered
+// Syntax errors are reported by Errors(), a program with errors must not be evaluated
 func (pars *Parser) ParseProgram() *tree.Root {
 	program := &tree.Root{}
 	program.Statements = []tree.Statement{}
 
 	for !pars.curTokenIs(lexer.EOF) {
-		stmt := pars.parseStatement()
+		stmt := pars.parseStatementRecover()
 		if stmt != nil {
 			program.Statements = append(program.Statements, stmt)
 		}
@@ -115,9 +119,74 @@ func (pars *Parser) ParseProgram() *tree.Root {
 	return program
 }
 
-// parse assign or print statement
+// parse a statement, after a syntax error skip to the next statement boundary
+// so that the errors of the following statements are reported too
+func (pars *Parser) parseStatementRecover() tree.Statement {
+	stmt := pars.parseStatement()
+	if pars.panicMode {
+		pars.synchronize()
+		return nil
+	}
+	return stmt
+}
+
+// skip tokens until the current token ends a statement or the next one starts a new one
+// nested blocks and hash literals are skipped as a whole
+func (pars *Parser) synchronize() {
+	pars.panicMode = false
+
+	// the ( left open by the statement are forgotten, so its newlines end it again
+	pars.parens = pars.blockParens
+
+	for !pars.curTokenIs(lexer.EOF) && !pars.peekTokenIs(lexer.EOF) {
+		if pars.braces <= pars.blockBraces {
+			if pars.curTokenIs(lexer.NEWLINE) {
+				return
+			}
+			switch pars.peekToken.Type {
+			case lexer.RBRAC, lexer.IF, lexer.WHILE, lexer.FOR, lexer.DO, lexer.PRINT,
+				lexer.BREAK, lexer.CONTINUE, lexer.FUNC, lexer.RETURN, lexer.VAR:
+				return
+			}
+		}
+		pars.nextToken()
+	}
+}
+
+// parse assign, print, selection, iteration or compound statement
 func (pars *Parser) parseStatement() tree.Statement {
 	switch pars.thisToken.Type {
+	case lexer.NEWLINE:
+		// empty line
+		return nil
+	case lexer.IF:
+		return pars.parseControlStatement(pars.parseIfExpression)
+	case lexer.WHILE:
+		return pars.parseControlStatement(pars.parseWhileExpression)
+	case lexer.FOR:
+		return pars.parseControlStatement(pars.parseForExpression)
+	case lexer.DO:
+		return pars.parseControlStatement(pars.parseDoWhileExpression)
+	case lexer.BREAK, lexer.CONTINUE:
+		return pars.parseLoopControlStatement()
+	case lexer.VAR:
+		stmt := pars.parseVarStatement()
+		if stmt == nil {
+			return nil
+		}
+		pars.endStatement()
+		return stmt
+	case lexer.FUNC:
+		return pars.parseFunctionStatement()
+	case lexer.RETURN:
+		return pars.parseReturnStatement()
+	case lexer.LBRAC:
+		block := pars.parseBlockStatement()
+		if block == nil {
+			return nil
+		}
+		pars.endStatement()
+		return block
 	case lexer.IDENT:
 		if pars.peekTokenIs(lexer.ASSIGN) {
 			return pars.parseAssignStatement()
@@ -131,11 +200,36 @@ func (pars *Parser) parseStatement() tree.Statement {
 }
 
 func (pars *Parser) parseAssignStatement() *tree.AssignStatement {
+	stmt := pars.parseAssignment()
+
+	pars.endStatement()
+
+	return stmt
+}
+
+// identifier = expression, without the end of the statement
+func (pars *Parser) parseAssignment() *tree.AssignStatement {
 	stmt := &tree.AssignStatement{Token: pars.thisToken}
 
 	stmt.Name = &tree.Identifier{Token: pars.thisToken, Value: pars.thisToken.Val}
 
-	// check if next token is =
+	// move past the =, the caller checked that it is the next token
+	pars.nextToken()
+	pars.nextToken()
+	stmt.Value = pars.parseExpression(LOWEST)
+
+	return stmt
+}
+
+// var identifier = expression, without the end of the statement
+func (pars *Parser) parseVarStatement() *tree.VarStatement {
+	stmt := &tree.VarStatement{Token: pars.thisToken}
+
+	if !pars.expectPeek(lexer.IDENT) {
+		return nil
+	}
+	stmt.Name = &tree.Identifier{Token: pars.thisToken, Value: pars.thisToken.Val}
+
 	if !pars.expectPeek(lexer.ASSIGN) {
 		return nil
 	}
@@ -143,10 +237,39 @@ func (pars *Parser) parseAssignStatement() *tree.AssignStatement {
 	pars.nextToken()
 	stmt.Value = pars.parseExpression(LOWEST)
 
-	if pars.peekTokenIs(lexer.NEWLINE) {
-		pars.nextToken()
+	return stmt
+}
+
+// parse the init and step of a for loop, a declaration, an assignment or an expression
+func (pars *Parser) parseSimpleStatement() tree.Statement {
+	if pars.curTokenIs(lexer.VAR) {
+		if stmt := pars.parseVarStatement(); stmt != nil {
+			return stmt
+		}
+		return nil
+	}
+	if pars.curTokenIs(lexer.IDENT) && pars.peekTokenIs(lexer.ASSIGN) {
+		return pars.parseAssignment()
 	}
 
+	return pars.parseExpressionOrIndexAssignment()
+}
+
+// parse break and continue, they are only allowed inside a loop
+func (pars *Parser) parseLoopControlStatement() tree.Statement {
+	if pars.loopDepth == 0 {
+		pars.errorAt(pars.thisToken, nil, "%s outside of a loop", pars.thisToken.Val)
+		return nil
+	}
+
+	var stmt tree.Statement
+	if pars.curTokenIs(lexer.BREAK) {
+		stmt = &tree.BreakStatement{Token: pars.thisToken}
+	} else {
+		stmt = &tree.ContinueStatement{Token: pars.thisToken}
+	}
+
+	pars.endStatement()
 	return stmt
 }
 
@@ -154,33 +277,96 @@ func (pars *Parser) parsePrintStatement() *tree.PrintStatement {
 	stmt := &tree.PrintStatement{Token: pars.thisToken}
 	pars.nextToken()
 
-	stmt.Value = pars.parseExpression(LOWEST)
+	stmt.Values = []tree.Expression{pars.parseExpression(LOWEST)}
 
-	// parse until carriage return
-	for !pars.curTokenIs(lexer.NEWLINE) {
+	// comma separated list of expressions
+	for pars.peekTokenIs(lexer.COMMA) {
+		pars.nextToken()
 		pars.nextToken()
+		stmt.Values = append(stmt.Values, pars.parseExpression(LOWEST))
 	}
 
+	pars.endStatement()
+
 	return stmt
 }
 
-func (pars *Parser) parseExpressionStatement() *tree.ExpressionStatement {
-	stmt := &tree.ExpressionStatement{Token: pars.thisToken}
+func (pars *Parser) parseExpressionStatement() tree.Statement {
+	stmt := pars.parseExpressionOrIndexAssignment()
+	if stmt == nil {
+		return nil
+	}
+
+	pars.endStatement()
+	return stmt
+}
 
+// an expression, or target[index] = expression when the expression is followed by =
+// without the end of the statement
+func (pars *Parser) parseExpressionOrIndexAssignment() tree.Statement {
+	stmt := &tree.ExpressionStatement{Token: pars.thisToken}
 	stmt.Expression = pars.parseExpression(LOWEST)
 
-	if pars.peekTokenIs(lexer.NEWLINE) {
-		pars.nextToken()
+	if stmt.Expression == nil || !pars.peekTokenIs(lexer.ASSIGN) {
+		return stmt
+	}
+
+	target, ok := stmt.Expression.(*tree.IndexExpression)
+	if !ok {
+		pars.errorAt(pars.peekToken, nil, "cannot assign to %s", stmt.Expression.String())
+		return nil
+	}
+
+	pars.nextToken()
+	assign := &tree.IndexAssignStatement{Token: pars.thisToken, Target: target}
+
+	pars.nextToken()
+	assign.Value = pars.parseExpression(LOWEST)
+
+	return assign
+}
+
+// parse if and loops, a single statement body ends the line itself
+// while a body in {} or the condition of do-while still needs the end of the line
+func (pars *Parser) parseControlStatement(parse prefixParseFn) tree.Statement {
+	stmt := &tree.ExpressionStatement{Token: pars.thisToken}
+
+	stmt.Expression = parse()
+	if stmt.Expression == nil {
+		return nil
+	}
+
+	if !pars.curTokenIs(lexer.NEWLINE) {
+		pars.endStatement()
 	}
 	return stmt
 }
 
+// a statement ends at a newline, at the } of its block, at the end of the program
+// or, as the body of if or do, before the else or while that follows it
+func (pars *Parser) endStatement() {
+	switch pars.peekToken.Type {
+	case lexer.NEWLINE:
+		pars.nextToken()
+	case lexer.RBRAC, lexer.EOF:
+	default:
+		if !pars.peekTokenIsFollow() {
+			pars.peekError(lexer.NEWLINE)
+		}
+	}
+}
+
+// parse an expression, returns nil after reporting an error
 func (pars *Parser) parseExpression(precedence int) tree.Expression {
 	prefix := pars.prefixParseFns[pars.thisToken.Type]
 	if prefix == nil {
+		pars.noPrefixParseFnError()
 		return nil
 	}
 	leftExp := prefix()
+	if leftExp == nil {
+		return nil
+	}
 
 	for !pars.peekTokenIs(lexer.NEWLINE) && precedence < pars.peekPrecedence() {
 		infix := pars.infixParseFns[pars.peekToken.Type]
@@ -191,6 +377,9 @@ func (pars *Parser) parseExpression(precedence int) tree.Expression {
 		pars.nextToken()
 
 		leftExp = infix(leftExp)
+		if leftExp == nil {
+			return nil
+		}
 	}
 	return leftExp
 }
@@ -202,14 +391,21 @@ func (pars *Parser) parseIdentifier() tree.Expression {
 func (pars *Parser) parseIntegerLiteral() tree.Expression {
 	lit := &tree.IntegerLiteral{Token: pars.thisToken}
 
-	value, err := strconv.ParseInt(pars.thisToken.Val, 0, 64)
-	_ = err
+	value, err := strconv.ParseInt(pars.thisToken.Val, 10, 64)
+	if err != nil {
+		pars.errorAt(pars.thisToken, nil, "invalid integer %q", pars.thisToken.Val)
+		return nil
+	}
 
 	lit.Value = value
 
 	return lit
 }
 
+func (pars *Parser) parseStringLiteral() tree.Expression {
+	return &tree.StringLiteral{Token: pars.thisToken, Value: pars.thisToken.Val}
+}
+
 // checks current token type
 func (pars *Parser) curTokenIs(t lexer.TokenType) bool {
 	return pars.thisToken.Type == t
@@ -220,12 +416,30 @@ func (pars *Parser) peekTokenIs(t lexer.TokenType) bool {
 	return pars.peekToken.Type == t
 }
 
-// checks if next token is what expected
+// checks if the next token may end a single statement body, see parseBody
+func (pars *Parser) peekTokenIsFollow() bool {
+	for _, t := range pars.follow {
+		if pars.peekTokenIs(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// move past the newlines that follow the current token
+func (pars *Parser) skipNewlines() {
+	for pars.peekTokenIs(lexer.NEWLINE) {
+		pars.nextToken()
+	}
+}
+
+// checks if next token is what expected, reports an error if not
 func (pars *Parser) expectPeek(t lexer.TokenType) bool {
 	if pars.peekTokenIs(t) {
 		pars.nextToken()
 		return true
 	}
+	pars.peekError(t)
 	return false
 }
 
@@ -253,14 +467,21 @@ func (pars *Parser) parseInfixExpression(left tree.Expression) tree.Expression {
 		Left:     left,
 	}
 
+	// a right associative operator lets the right operand take an operator of the same precedence
 	precedence := pars.curPrecedence()
+	if operators[pars.thisToken.Type].Associativity == RIGHT_ASSOC {
+		precedence--
+	}
 	pars.nextToken()
 	expression.Right = pars.parseExpression(precedence)
+	if expression.Right == nil {
+		return nil
+	}
 
 	return expression
 }
 
-// parse expressions with ( prefix
+// parse unary operators -, + and !, they bind tighter than every binary operator
 func (pars *Parser) parsePrefixExpression() tree.Expression {
 	expression := &tree.PrefixExpression{
 		Token:    pars.thisToken,
@@ -270,6 +491,9 @@ func (pars *Parser) parsePrefixExpression() tree.Expression {
 	pars.nextToken()
 
 	expression.Right = pars.parseExpression(PREFIX)
+	if expression.Right == nil {
+		return nil
+	}
 	return expression
 }
 
@@ -278,7 +502,7 @@ func (pars *Parser) parseGroupedExpression() tree.Expression {
 	pars.nextToken()
 
 	exp := pars.parseExpression(LOWEST)
-	if !pars.expectPeek(lexer.RPAR) {
+	if exp == nil || !pars.expectPeek(lexer.RPAR) {
 		return nil
 	}
 	return exp
@@ -290,21 +514,44 @@ func (pars *Parser) parseIfExpression() tree.Expression {
 	if !pars.expectPeek(lexer.LPAR) {
 		return nil
 	}
+	header := pars.parens
 
 	pars.nextToken()
 	expression.Condition = pars.parseExpression(LOWEST)
 
-	if !pars.expectPeek(lexer.RPAR) {
+	if expression.Condition == nil || !pars.expectPeek(lexer.RPAR) {
+		pars.skipIf(header)
+		return nil
+	}
+
+	expression.TrueBranch = pars.parseBody(lexer.ELSE)
+	if expression.TrueBranch == nil {
 		return nil
 	}
 
-	expression.TrueBranch = pars.parseBlockStatement()
+	// else may be on one of the following lines, it belongs to the closest if
+	pars.skipNewlines()
+	if !pars.peekTokenIs(lexer.ELSE) {
+		return expression
+	}
+	pars.nextToken()
 
-	if pars.peekTokenIs(lexer.ELSE) {
+	// else if chains become nested if expressions
+	if pars.peekTokenIs(lexer.IF) {
 		pars.nextToken()
+		elseIf := pars.parseIfExpression()
+		if elseIf == nil {
+			return nil
+		}
+		expression.FalseBranch = elseIf
+		return expression
+	}
 
-		expression.FalseBranch = pars.parseBlockStatement()
+	falseBranch := pars.parseBody()
+	if falseBranch == nil {
+		return nil
 	}
+	expression.FalseBranch = falseBranch
 	return expression
 }
 
@@ -314,32 +561,202 @@ func (pars *Parser) parseWhileExpression() tree.Expression {
 	if !pars.expectPeek(lexer.LPAR) {
 		return nil
 	}
+	header := pars.parens
 
 	pars.nextToken()
 	expression.Condition = pars.parseExpression(LOWEST)
 
-	if !pars.expectPeek(lexer.RPAR) {
+	if expression.Condition == nil || !pars.expectPeek(lexer.RPAR) {
+		pars.skipLoop(header)
+		return nil
+	}
+
+	pars.loopDepth++
+	expression.Action = pars.parseBody()
+	pars.loopDepth--
+	if expression.Action == nil {
+		return nil
+	}
+
+	return expression
+}
+
+// for (init; condition; step) body, each of the three parts may be empty
+func (pars *Parser) parseForExpression() tree.Expression {
+	expression := &tree.ForExpression{Token: pars.thisToken}
+
+	if !pars.expectPeek(lexer.LPAR) {
+		return nil
+	}
+	header := pars.parens
+
+	if !pars.peekTokenIs(lexer.SEMICOL) {
+		pars.nextToken()
+		expression.Init = pars.parseSimpleStatement()
+	}
+	if pars.panicMode || !pars.expectPeek(lexer.SEMICOL) {
+		pars.skipLoop(header)
+		return nil
+	}
+
+	if !pars.peekTokenIs(lexer.SEMICOL) {
+		pars.nextToken()
+		expression.Condition = pars.parseExpression(LOWEST)
+	}
+	if pars.panicMode || !pars.expectPeek(lexer.SEMICOL) {
+		pars.skipLoop(header)
+		return nil
+	}
+
+	if !pars.peekTokenIs(lexer.RPAR) {
+		pars.nextToken()
+		expression.Step = pars.parseSimpleStatement()
+	}
+	if pars.panicMode || !pars.expectPeek(lexer.RPAR) {
+		pars.skipLoop(header)
+		return nil
+	}
+
+	pars.loopDepth++
+	expression.Action = pars.parseBody()
+	pars.loopDepth--
+	if expression.Action == nil {
+		return nil
+	}
+
+	return expression
+}
+
+// after a syntax error in the header of an if, while or for, skip to the ) that closes it,
+// header is the number of ( open after the ( of the header
+// false if the header is not closed before the { of the body or the end of the program
+func (pars *Parser) skipHeader(header int) bool {
+	for !pars.curTokenIs(lexer.RPAR) || pars.parens >= header {
+		if pars.peekTokenIs(lexer.LBRAC) || pars.peekTokenIs(lexer.EOF) {
+			return false
+		}
+		pars.nextToken()
+	}
+	return true
+}
+
+// after a syntax error in the header of a while or for loop, skip the rest of the header
+// and the body, so that recovery does not stop at a break or continue of the body and
+// report it as outside of a loop
+// the body is parsed as a loop body while the error still keeps further errors quiet
+func (pars *Parser) skipLoop(header int) {
+	if !pars.skipHeader(header) {
+		return
+	}
+
+	pars.loopDepth++
+	pars.parseBody()
+	pars.loopDepth--
+}
+
+// after a syntax error in the header of an if, skip the rest of the header, the true branch
+// and any else chain, so that recovery does not stop at the else and report it
+func (pars *Parser) skipIf(header int) {
+	if !pars.skipHeader(header) {
+		return
+	}
+	pars.parseBody(lexer.ELSE)
+
+	pars.skipNewlines()
+	if !pars.peekTokenIs(lexer.ELSE) {
+		return
+	}
+	pars.nextToken()
+
+	if pars.peekTokenIs(lexer.IF) {
+		pars.nextToken()
+		pars.parseIfExpression()
+		return
+	}
+	pars.parseBody()
+}
+
+// do body while (condition), the while may be on the line after the body
+func (pars *Parser) parseDoWhileExpression() tree.Expression {
+	expression := &tree.DoWhileExpression{Token: pars.thisToken}
+
+	pars.loopDepth++
+	expression.Action = pars.parseBody(lexer.WHILE)
+	pars.loopDepth--
+	if expression.Action == nil {
 		return nil
 	}
 
-	expression.Action = pars.parseBlockStatement()
+	pars.skipNewlines()
+	if !pars.expectPeek(lexer.WHILE) || !pars.expectPeek(lexer.LPAR) {
+		return nil
+	}
+
+	pars.nextToken()
+	expression.Condition = pars.parseExpression(LOWEST)
+
+	if expression.Condition == nil || !pars.expectPeek(lexer.RPAR) {
+		return nil
+	}
 
 	return expression
 }
 
-// parse block of statements in {}
+// parse the body of if, else and loops: a block in {} or a single statement,
+// which may start on the next line
+// follow are the tokens that may come right after a single statement on the same line,
+// besides the ones of the bodies around it: in do if (x) a while (x), a ends before while
+func (pars *Parser) parseBody(follow ...lexer.TokenType) *tree.BlockStatement {
+	pars.skipNewlines()
+	pars.nextToken()
+
+	if pars.curTokenIs(lexer.LBRAC) {
+		return pars.parseBlockStatement()
+	}
+
+	block := &tree.BlockStatement{Token: pars.thisToken}
+
+	outerFollow := pars.follow
+	pars.follow = append(outerFollow[:len(outerFollow):len(outerFollow)], follow...)
+	stmt := pars.parseStatement()
+	pars.follow = outerFollow
+
+	if stmt == nil || pars.panicMode {
+		return nil
+	}
+	block.Statements = []tree.Statement{stmt}
+	return block
+}
+
+// parse block of statements in {}, the current token is the {
 func (pars *Parser) parseBlockStatement() *tree.BlockStatement {
 	block := &tree.BlockStatement{Token: pars.thisToken}
 	block.Statements = []tree.Statement{}
 
+	// statements inside the block end at newlines again
+	outerFollow, outerBlockBraces, outerBlockParens := pars.follow, pars.blockBraces, pars.blockParens
+	pars.follow, pars.blockBraces, pars.blockParens = nil, pars.braces, pars.parens
+	defer func() {
+		pars.follow, pars.blockBraces, pars.blockParens = outerFollow, outerBlockBraces, outerBlockParens
+		pars.dropNewlines()
+	}()
+
 	pars.nextToken()
 
 	for !pars.curTokenIs(lexer.RBRAC) && !pars.curTokenIs(lexer.EOF) {
-		stmt := pars.parseStatement()
+		stmt := pars.parseStatementRecover()
 		if stmt != nil {
 			block.Statements = append(block.Statements, stmt)
 		}
 		pars.nextToken()
 	}
+
+	if !pars.curTokenIs(lexer.RBRAC) {
+		pars.errorAt(pars.thisToken, []lexer.TokenType{lexer.RBRAC},
+			"expected \"}\" to close the block opened at %s, found %s",
+			block.Token.Pos, describeToken(pars.thisToken))
+		return nil
+	}
+	block.EndToken = pars.thisToken
 	return block
 }
@@ -0,0 +1,198 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+	"toy_interpreter_go/lexer"
+)
+
+func TestOperatorPrecedence(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		// same precedence groups from the left
+		{"a + b - c", "((a + b) - c)"},
+		{"a * b / c % d", "(((a * b) / c) % d)"},
+		{"a - b - c", "((a - b) - c)"},
+		{"a == b != c", "((a == b) != c)"},
+		{"a < b > c", "((a < b) > c)"},
+		{"a || b || c", "((a || b) || c)"},
+		{"a && b && c", "((a && b) && c)"},
+
+		// multiplicative binds tighter than additive
+		{"a + b * c", "(a + (b * c))"},
+		{"a * b + c", "((a * b) + c)"},
+		{"a - b % c", "(a - (b % c))"},
+
+		// additive binds tighter than relational
+		{"a + b < c - d", "((a + b) < (c - d))"},
+		{"a <= b + c", "(a <= (b + c))"},
+
+		// relational binds tighter than equality
+		{"a < b == c > d", "((a < b) == (c > d))"},
+		{"a != b >= c", "(a != (b >= c))"},
+
+		// equality binds tighter than &&
+		{"a == b && c != d", "((a == b) && (c != d))"},
+
+		// && binds tighter than ||
+		{"a || b && c", "(a || (b && c))"},
+		{"a && b || c", "((a && b) || c)"},
+		{"a || b && c || d", "((a || (b && c)) || d)"},
+
+		// parentheses force the grouping
+		{"(a + b) * c", "((a + b) * c)"},
+		{"a * (b + c)", "(a * (b + c))"},
+		{"(a || b) && c", "((a || b) && c)"},
+
+		// unary operators bind tighter than binary ones
+		{"-a", "(-a)"},
+		{"!a", "(!a)"},
+		{"+a", "(+a)"},
+		{"-a * b", "((-a) * b)"},
+		{"a - -b", "(a - (-b))"},
+		{"a + -b * c", "(a + ((-b) * c))"},
+		{"!a && b", "((!a) && b)"},
+		{"!!a", "(!(!a))"},
+		{"-(a + b)", "(-(a + b))"},
+		{"!(a == b) || -c < d", "((!(a == b)) || ((-c) < d))"},
+
+		// calls bind tighter than every operator
+		{"-f(a)", "(-f(a))"},
+		{"a + f(b, c * d) * e", "(a + (f(b, (c * d)) * e))"},
+		{"f(a)(b)", "f(a)(b)"},
+
+		// indexing binds like a call
+		{"-a[0]", "(-(a[0]))"},
+		{"a * b[1] + c", "((a * (b[1])) + c)"},
+		{"a[b[0]][i + 1]", "((a[(b[0])])[(i + 1)])"},
+		{"f(a)[0]", "(f(a)[0])"},
+		{"[a, b * c][0]", "([a, (b * c)][0])"},
+		{"f({a: b * c, 1: d})[e]", "(f({a: (b * c), 1: d})[e])"},
+
+		// the expression of example1.cmm
+		{"m - 3 + n / 2 * (0 - 5 + m * n % 4)", "((m - 3) + ((n / 2) * ((0 - 5) + ((m * n) % 4))))"},
+		{"m > n || n >= p", "((m > n) || (n >= p))"},
+	}
+
+	for _, tt := range tests {
+		pars := ParsConstructor(lexer.LexConstructor(tt.input))
+		program := pars.ParseProgram()
+
+		for _, err := range pars.Errors() {
+			t.Errorf("%q: unexpected parse error: %s", tt.input, err)
+		}
+		if len(program.Statements) != 1 {
+			t.Errorf("%q: expected 1 statement, got %d", tt.input, len(program.Statements))
+			continue
+		}
+		if got := program.String(); got != tt.expected {
+			t.Errorf("%q: expected %s, got %s", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestOperatorTable(t *testing.T) {
+	seen := make(map[lexer.TokenType]bool)
+	for _, op := range Operators {
+		if seen[op.Token] {
+			t.Errorf("operator %q appears twice in the table", op.Token)
+		}
+		seen[op.Token] = true
+
+		if op.Precedence <= LOWEST || op.Precedence >= PREFIX {
+			t.Errorf("operator %q: precedence %d outside the binary range", op.Token, op.Precedence)
+		}
+	}
+}
+
+func TestSingleStatementBodies(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"do if (x) print 1 while (x)", "do { if (x) { print 1 } } while (x)"},
+		{"do if (x) print 1 else print 2 while (x)", "do { if (x) { print 1 } else { print 2 } } while (x)"},
+		{"if (a) if (b) print 1 else print 2 else print 3", "if (a) { if (b) { print 1 } else { print 2 } } else { print 3 }"},
+		{"if (a) while (b) b = 0 else print 3", "if (a) { while (b) { b = 0 } } else { print 3 }"},
+		{"do do x = 1 while (y) while (z)", "do { do { x = 1 } while (y) } while (z)"},
+	}
+
+	for _, tt := range tests {
+		pars := ParsConstructor(lexer.LexConstructor(tt.input))
+		program := pars.ParseProgram()
+
+		for _, err := range pars.Errors() {
+			t.Errorf("%q: unexpected parse error: %s", tt.input, err)
+		}
+		if got := program.String(); got != tt.expected {
+			t.Errorf("%q: expected %s, got %s", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestMultiLineLists(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x = [1,\n2]", "x = [1, 2]"},
+		{"x = [\n  1,\n  2,\n]", "x = [1, 2]"},
+		{"x = [\n]", "x = []"},
+		{"f(a,\n  b * c\n)", "f(a, (b * c))"},
+		{"f(\n  [1,\n   2],\n  {\"k\": 3}\n)", "f([1, 2], {\"k\": 3})"},
+		{"x = (1 +\n  2)", "x = (1 + 2)"},
+		{"if (a &&\n  b) print 1", "if (a && b) { print 1 }"},
+		{"while (\n  a\n) a = 0", "while (a) { a = 0 }"},
+		{"for (i = 0;\n  i < 2;\n  i = i + 1) print i", "for (i = 0; (i < 2); i = (i + 1)) { print i }"},
+		{"f(fn(x) {\n  y = x\n  return y\n},\n  1)", "f(fn(x) { y = x; return y }, 1)"},
+	}
+
+	for _, tt := range tests {
+		pars := ParsConstructor(lexer.LexConstructor(tt.input))
+		program := pars.ParseProgram()
+
+		for _, err := range pars.Errors() {
+			t.Errorf("%q: unexpected parse error: %s", tt.input, err)
+		}
+		if got := program.String(); got != tt.expected {
+			t.Errorf("%q: expected %s, got %s", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestLoopHeaderRecovery(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"for (x = 0, 1; ;) break", []string{`1:11: expected ";", found ","`}},
+		{"while (x y) continue", []string{`1:10: expected ")", found "y"`}},
+		{"while (x y) if (a) break\nprint 1 1", []string{`1:10: expected ")", found "y"`, `2:9: expected newline, found "1"`}},
+		{"for (x = 0, 1; ;) {\n  break\n  continue\n}", []string{`1:11: expected ";", found ","`}},
+		{"while (x y {\n  break\n}", []string{`1:10: expected ")", found "y"`}},
+		{"if (x y) print 1 else print 2", []string{`1:7: expected ")", found "y"`}},
+		{"if (x y) print 1\nelse print 2", []string{`1:7: expected ")", found "y"`}},
+		{"if (x y) {\n  print 1\n} else if (z) {\n  print 2\n} else print 3", []string{`1:7: expected ")", found "y"`}},
+		{"while (1) if (x y) break else continue", []string{`1:17: expected ")", found "y"`}},
+		{"if (x y) print 1\nprint 2 2", []string{`1:7: expected ")", found "y"`, `2:9: expected newline, found "2"`}},
+		{"if (a) print 1 else if (x y) print 2 else print 3", []string{`1:27: expected ")", found "y"`}},
+	}
+
+	for _, tt := range tests {
+		pars := ParsConstructor(lexer.LexConstructor(tt.input))
+		pars.ParseProgram()
+
+		errors := pars.Errors()
+		if len(errors) != len(tt.expected) {
+			t.Errorf("%q: expected %d errors, got %d: %v", tt.input, len(tt.expected), len(errors), errors)
+			continue
+		}
+		for i, err := range errors {
+			if !strings.HasSuffix(err.Error(), tt.expected[i]) {
+				t.Errorf("%q: expected error %q, got %q", tt.input, tt.expected[i], err)
+			}
+		}
+	}
+}
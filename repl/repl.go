@@ -0,0 +1,168 @@
+package repl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"toy_interpreter_go/evaluator"
+	"toy_interpreter_go/lexer"
+	"toy_interpreter_go/object"
+	"toy_interpreter_go/parser"
+	"toy_interpreter_go/tree"
+)
+
+const (
+	PROMPT       = ">> "
+	CONTINUATION = ".. "
+)
+
+const help = `Enter statements to run them, the value of an expression statement is printed.
+Input continues on the next line while a {, [ or ( is left open,
+so a block, a list or anything in ( ) may span several lines.
+Commands:
+  :env          list the variables and their values
+  :ast          toggle printing the parsed statements before running them
+  :reset        forget every variable
+  :load file    run a program file in the current environment
+  :help         print this message
+  :quit         leave the repl
+`
+
+// Repl : state of an interactive session, the environment lives across lines
+type Repl struct {
+	out     io.Writer
+	env     *object.Environment
+	eval    *evaluator.Evaluator
+	showAST bool
+}
+
+// Start : read statements from in and evaluate them until the input ends or :quit
+func Start(in io.Reader, out io.Writer) {
+	repl := &Repl{
+		out:  out,
+		env:  object.NewEnvironment(),
+		eval: evaluator.EvalConstructor(out),
+	}
+	scanner := bufio.NewScanner(in)
+
+	for {
+		fmt.Fprint(out, PROMPT)
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return
+		}
+		line := scanner.Text()
+
+		if strings.HasPrefix(strings.TrimSpace(line), ":") {
+			if !repl.command(strings.TrimSpace(line)) {
+				return
+			}
+			continue
+		}
+
+		// keep reading while a block or a list is open
+		input := line + "\n"
+		for unfinished(input) {
+			fmt.Fprint(out, CONTINUATION)
+			if !scanner.Scan() {
+				break
+			}
+			input += scanner.Text() + "\n"
+		}
+
+		repl.run("", input)
+	}
+}
+
+// command : run a meta-command, returns false when the session should end
+func (repl *Repl) command(line string) bool {
+	fields := strings.Fields(line)
+
+	switch fields[0] {
+	case ":quit", ":q":
+		return false
+	case ":help":
+		fmt.Fprint(repl.out, help)
+	case ":env":
+		for _, name := range repl.env.Names() {
+			val, _ := repl.env.Get(name)
+			fmt.Fprintf(repl.out, "%s = %s\n", name, val.Inspect())
+		}
+	case ":ast":
+		repl.showAST = !repl.showAST
+		fmt.Fprintf(repl.out, "printing parsed statements: %t\n", repl.showAST)
+	case ":reset":
+		repl.env = object.NewEnvironment()
+	case ":load":
+		if len(fields) != 2 {
+			fmt.Fprintln(repl.out, "usage: :load file")
+			break
+		}
+		content, err := os.ReadFile(fields[1])
+		if err != nil {
+			fmt.Fprintln(repl.out, err)
+			break
+		}
+		repl.run(fields[1], string(content))
+	default:
+		fmt.Fprintf(repl.out, "unknown command %s, :help lists the commands\n", fields[0])
+	}
+	return true
+}
+
+// run : parse and evaluate a piece of program in the session environment
+func (repl *Repl) run(file, input string) {
+	pars := parser.ParsConstructor(lexer.LexFileConstructor(file, input))
+	program := pars.ParseProgram()
+
+	if errs := pars.Errors(); len(errs) > 0 {
+		for _, e := range errs {
+			fmt.Fprintln(repl.out, e)
+		}
+		return
+	}
+
+	if repl.showAST {
+		for _, stmt := range program.Statements {
+			fmt.Fprintln(repl.out, stmt.String())
+		}
+	}
+
+	evaluated := repl.eval.Eval(program, repl.env)
+	if evaluated == nil {
+		return
+	}
+
+	// only print the value of a trailing expression statement, or an error
+	if rtErr, ok := evaluated.(*object.Error); ok {
+		fmt.Fprintln(repl.out, rtErr)
+	} else if endsWithExpression(program) {
+		fmt.Fprintln(repl.out, evaluated.Inspect())
+	}
+}
+
+// unfinished : reports whether the input opens more {, [ and ( than it closes,
+// blocks, hash and array literals and anything in ( ) may go on over several lines
+func unfinished(input string) bool {
+	lex := lexer.LexConstructor(input)
+	depth := 0
+	for tok := lex.NextToken(); tok.Type != lexer.EOF; tok = lex.NextToken() {
+		switch tok.Type {
+		case lexer.LBRAC, lexer.LSQUARE, lexer.LPAR:
+			depth++
+		case lexer.RBRAC, lexer.RSQUARE, lexer.RPAR:
+			depth--
+		}
+	}
+	return depth > 0
+}
+
+func endsWithExpression(program *tree.Root) bool {
+	if len(program.Statements) == 0 {
+		return false
+	}
+	_, ok := program.Statements[len(program.Statements)-1].(*tree.ExpressionStatement)
+	return ok
+}
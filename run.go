@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+	"toy_interpreter_go/evaluator"
+	"toy_interpreter_go/lexer"
+	"toy_interpreter_go/object"
+	"toy_interpreter_go/parser"
+)
+
+// runCommand : toy_interpreter_go run [flags] file...
+// every file is run with its own environment, the output of all of them goes to the same place
+func runCommand(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	output := flags.String("o", "", "write the program output to `file` instead of the standard output")
+	dumpTokens := flags.Bool("dump-tokens", false, "print the tokens of every program before running it")
+	dumpAST := flags.Bool("dump-ast", false, "print the parsed statements of every program before running it")
+	timeout := flags.Duration("timeout", 0, "stop every program that runs longer than `duration`, like 500ms or 2s; 0 for no limit")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitFailure
+	}
+	if flags.NArg() == 0 {
+		fmt.Fprintln(stderr, "run: no program files")
+		return exitFailure
+	}
+
+	destination := stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitFailure
+		}
+		defer file.Close()
+		destination = file
+	}
+
+	eval := evaluator.EvalConstructor(destination)
+
+	// keep going after a failing program so that every file is checked,
+	// the exit code is the one of the first failure
+	status := exitOK
+	for _, src := range flags.Args() {
+		code := interpret(src, stdin, eval, stdout, stderr, *dumpTokens, *dumpAST, *timeout)
+		if status == exitOK {
+			status = code
+		}
+	}
+	return status
+}
+
+// interpret : read, parse and evaluate one program, returns the exit code
+// the time limit starts once the program is parsed
+func interpret(src string, stdin io.Reader, eval *evaluator.Evaluator, stdout, stderr io.Writer, dumpTokens, dumpAST bool, timeout time.Duration) int {
+	name, code, err := readProgram(src, stdin)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitFailure
+	}
+
+	if dumpTokens {
+		printTokens(stdout, lexer.LexFileConstructor(name, code))
+	}
+
+	pars := parser.ParsConstructor(lexer.LexFileConstructor(name, code))
+	program := pars.ParseProgram()
+
+	// a program with syntax errors is never evaluated
+	if errs := pars.Errors(); len(errs) > 0 {
+		for _, e := range errs {
+			fmt.Fprintln(stderr, e)
+		}
+		return exitSyntax
+	}
+
+	if dumpAST {
+		for _, stmt := range program.Statements {
+			fmt.Fprintf(stdout, "%s\t%s\n", stmt.Pos(), stmt.String())
+		}
+	}
+
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	env := object.NewEnvironment()
+	evaluated := eval.EvalContext(ctx, program, env)
+
+	if err := eval.Flush(); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitFailure
+	}
+
+	// a runtime error stops the program, the output printed before it is kept
+	if rtErr, ok := evaluated.(*object.Error); ok {
+		fmt.Fprintln(stderr, rtErr)
+		return exitRuntime
+	}
+	return exitOK
+}
+
+// readProgram : the name and the source code of a program file, "-" is the standard input
+func readProgram(src string, stdin io.Reader) (string, string, error) {
+	if src == "-" {
+		content, err := io.ReadAll(stdin)
+		return "<stdin>", string(content), err
+	}
+
+	sourceFileStat, err := os.Stat(src)
+	if err != nil {
+		return "", "", err
+	}
+
+	if !sourceFileStat.Mode().IsRegular() {
+		return "", "", fmt.Errorf("%s is not a regular file", src)
+	}
+
+	content, err := os.ReadFile(src)
+	return src, string(content), err
+}
+
+// printTokens : one token per line with its position
+func printTokens(out io.Writer, lex *lexer.Lexer) {
+	for {
+		tok := lex.NextToken()
+		tokType := string(tok.Type)
+		if tok.Type == lexer.NEWLINE {
+			tokType = "NEWLINE"
+		}
+		fmt.Fprintf(out, "%s\t%s\t%q\n", tok.Pos, tokType, tok.Val)
+		if tok.Type == lexer.EOF {
+			return
+		}
+	}
+}
@@ -2,6 +2,8 @@ package tree
 
 import (
 	"bytes"
+	"strconv"
+	"strings"
 	"toy_interpreter_go/lexer"
 )
 
@@ -10,6 +12,9 @@ type TreeNode interface {
 	// TokenVal() returns the value of the token
 	TokenVal() string
 	String() string
+	// Pos() and End() return the source range of the node
+	Pos() lexer.Position
+	End() lexer.Position
 }
 
 // Statement : statement type
@@ -29,11 +34,14 @@ type Root struct {
 	Statements []Statement
 }
 
-// The whole programm as a string
+// The whole programm as a string, one statement per line
 func (r *Root) String() string {
 	var out bytes.Buffer
 
-	for _, s := range r.Statements {
+	for i, s := range r.Statements {
+		if i > 0 {
+			out.WriteString("\n")
+		}
 		out.WriteString(s.String())
 	}
 
@@ -48,6 +56,38 @@ func (r *Root) TokenVal() string {
 	return ""
 }
 
+// Pos : start of the first statement
+func (r *Root) Pos() lexer.Position {
+	if len(r.Statements) > 0 {
+		return r.Statements[0].Pos()
+	}
+	return lexer.Position{}
+}
+
+// End : end of the last statement
+func (r *Root) End() lexer.Position {
+	if len(r.Statements) > 0 {
+		return r.Statements[len(r.Statements)-1].End()
+	}
+	return lexer.Position{}
+}
+
+// start of a child node that may be missing after a parse error
+func posOf(node TreeNode, fallback lexer.Token) lexer.Position {
+	if node == nil {
+		return fallback.Pos
+	}
+	return node.Pos()
+}
+
+// end of a child node that may be missing after a parse error
+func endOf(node TreeNode, fallback lexer.Token) lexer.Position {
+	if node == nil {
+		return fallback.End
+	}
+	return node.End()
+}
+
 // AssignStatement : Assignment
 type AssignStatement struct {
 	Token lexer.Token
@@ -55,8 +95,15 @@ type AssignStatement struct {
 	Value Expression
 }
 
-func (as *AssignStatement) statementNode()   {}
-func (as *AssignStatement) TokenVal() string { return as.Token.Val }
+func (as *AssignStatement) statementNode()      {}
+func (as *AssignStatement) TokenVal() string    { return as.Token.Val }
+func (as *AssignStatement) Pos() lexer.Position { return as.Token.Pos }
+func (as *AssignStatement) End() lexer.Position {
+	if as.Value == nil {
+		return as.Name.End()
+	}
+	return as.Value.End()
+}
 func (as *AssignStatement) String() string {
 	var out bytes.Buffer
 
@@ -70,30 +117,98 @@ func (as *AssignStatement) String() string {
 	return out.String()
 }
 
+// IndexAssignStatement : target[index] = expression, changes an element of an array or hash
+type IndexAssignStatement struct {
+	Token  lexer.Token // the = token
+	Target *IndexExpression
+	Value  Expression
+}
+
+func (ia *IndexAssignStatement) statementNode()      {}
+func (ia *IndexAssignStatement) TokenVal() string    { return ia.Token.Val }
+func (ia *IndexAssignStatement) Pos() lexer.Position { return ia.Target.Pos() }
+func (ia *IndexAssignStatement) End() lexer.Position { return endOf(ia.Value, ia.Token) }
+func (ia *IndexAssignStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ia.Target.String())
+	out.WriteString(" = ")
+
+	if ia.Value != nil {
+		out.WriteString(ia.Value.String())
+	}
+
+	return out.String()
+}
+
+// VarStatement : declaration of a variable in the current scope
+type VarStatement struct {
+	Token lexer.Token // the 'var' token
+	Name  *Identifier
+	Value Expression
+}
+
+func (vs *VarStatement) statementNode()      {}
+func (vs *VarStatement) TokenVal() string    { return vs.Token.Val }
+func (vs *VarStatement) Pos() lexer.Position { return vs.Token.Pos }
+func (vs *VarStatement) End() lexer.Position {
+	if vs.Value == nil {
+		return vs.Name.End()
+	}
+	return vs.Value.End()
+}
+func (vs *VarStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("var ")
+	out.WriteString(vs.Name.String())
+	out.WriteString(" = ")
+
+	if vs.Value != nil {
+		out.WriteString(vs.Value.String())
+	}
+
+	return out.String()
+}
+
 // Identifier - to hold the identifier of the binding
 type Identifier struct {
 	Token lexer.Token // IDENT token
 	Value string
 }
 
-func (i *Identifier) expressionNode()  {}
-func (i *Identifier) TokenVal() string { return i.Token.Val }
-func (i *Identifier) String() string   { return i.Value }
+func (i *Identifier) expressionNode()     {}
+func (i *Identifier) TokenVal() string    { return i.Token.Val }
+func (i *Identifier) Pos() lexer.Position { return i.Token.Pos }
+func (i *Identifier) End() lexer.Position { return i.Token.End }
+func (i *Identifier) String() string      { return i.Value }
 
 // PrintStatement : Print statement
 type PrintStatement struct {
-	Token lexer.Token // PRINT token
-	Value Expression
+	Token  lexer.Token // PRINT token
+	Values []Expression
 }
 
-func (ps *PrintStatement) statementNode()   {}
-func (ps *PrintStatement) TokenVal() string { return ps.Token.Val }
+func (ps *PrintStatement) statementNode()      {}
+func (ps *PrintStatement) TokenVal() string    { return ps.Token.Val }
+func (ps *PrintStatement) Pos() lexer.Position { return ps.Token.Pos }
+func (ps *PrintStatement) End() lexer.Position {
+	if len(ps.Values) == 0 {
+		return ps.Token.End
+	}
+	return endOf(ps.Values[len(ps.Values)-1], ps.Token)
+}
 func (ps *PrintStatement) String() string {
 	var out bytes.Buffer
 
 	out.WriteString(ps.TokenVal() + " ")
-	if ps.Value != nil {
-		out.WriteString(ps.Value.String())
+	for i, v := range ps.Values {
+		if i > 0 {
+			out.WriteString(", ")
+		}
+		if v != nil {
+			out.WriteString(v.String())
+		}
 	}
 
 	// out.WriteString("\n") // Sunday
@@ -106,8 +221,10 @@ type ExpressionStatement struct {
 	Expression Expression
 }
 
-func (es *ExpressionStatement) statementNode()   {}
-func (es *ExpressionStatement) TokenVal() string { return es.Token.Val }
+func (es *ExpressionStatement) statementNode()      {}
+func (es *ExpressionStatement) TokenVal() string    { return es.Token.Val }
+func (es *ExpressionStatement) Pos() lexer.Position { return posOf(es.Expression, es.Token) }
+func (es *ExpressionStatement) End() lexer.Position { return endOf(es.Expression, es.Token) }
 func (es *ExpressionStatement) String() string {
 	if es.Expression != nil {
 		return es.Expression.String()
@@ -121,9 +238,23 @@ type IntegerLiteral struct {
 	Value int64
 }
 
-func (il *IntegerLiteral) expressionNode()  {}
-func (il *IntegerLiteral) TokenVal() string { return il.Token.Val }
-func (il *IntegerLiteral) String() string   { return il.Token.Val }
+func (il *IntegerLiteral) expressionNode()     {}
+func (il *IntegerLiteral) TokenVal() string    { return il.Token.Val }
+func (il *IntegerLiteral) Pos() lexer.Position { return il.Token.Pos }
+func (il *IntegerLiteral) End() lexer.Position { return il.Token.End }
+func (il *IntegerLiteral) String() string      { return il.Token.Val }
+
+// StringLiteral : string in double quotes, Value has the escape sequences replaced
+type StringLiteral struct {
+	Token lexer.Token
+	Value string
+}
+
+func (sl *StringLiteral) expressionNode()     {}
+func (sl *StringLiteral) TokenVal() string    { return sl.Token.Val }
+func (sl *StringLiteral) Pos() lexer.Position { return sl.Token.Pos }
+func (sl *StringLiteral) End() lexer.Position { return sl.Token.End }
+func (sl *StringLiteral) String() string      { return strconv.Quote(sl.Value) }
 
 // InfixExpression : operators like +, - etc
 type InfixExpression struct {
@@ -133,8 +264,10 @@ type InfixExpression struct {
 	Right    Expression
 }
 
-func (ie *InfixExpression) expressionNode()  {}
-func (ie *InfixExpression) TokenVal() string { return ie.Token.Val }
+func (ie *InfixExpression) expressionNode()     {}
+func (ie *InfixExpression) TokenVal() string    { return ie.Token.Val }
+func (ie *InfixExpression) Pos() lexer.Position { return posOf(ie.Left, ie.Token) }
+func (ie *InfixExpression) End() lexer.Position { return endOf(ie.Right, ie.Token) }
 func (ie *InfixExpression) String() string {
 	var out bytes.Buffer
 
@@ -147,14 +280,17 @@ func (ie *InfixExpression) String() string {
 	return out.String()
 }
 
+// PrefixExpression : unary operators like -, ! etc
 type PrefixExpression struct {
-	Token    lexer.Token // prefix for (
+	Token    lexer.Token // The operator token, e.g. -
 	Operator string
 	Right    Expression
 }
 
-func (pe *PrefixExpression) expressionNode()  {}
-func (pe *PrefixExpression) TokenVal() string { return pe.Token.Val }
+func (pe *PrefixExpression) expressionNode()     {}
+func (pe *PrefixExpression) TokenVal() string    { return pe.Token.Val }
+func (pe *PrefixExpression) Pos() lexer.Position { return pe.Token.Pos }
+func (pe *PrefixExpression) End() lexer.Position { return endOf(pe.Right, pe.Token) }
 func (pe *PrefixExpression) String() string {
 	var out bytes.Buffer
 
@@ -166,45 +302,64 @@ func (pe *PrefixExpression) String() string {
 	return out.String()
 }
 
+// IfExpression : selection, an else if chain is a nested IfExpression in FalseBranch
 type IfExpression struct {
 	Token       lexer.Token // The 'if' token
 	Condition   Expression
 	TrueBranch  *BlockStatement
-	FalseBranch *BlockStatement
+	FalseBranch TreeNode // *BlockStatement, *IfExpression for else if, nil without else
 }
 
-func (ie *IfExpression) expressionNode()  {}
-func (ie *IfExpression) TokenVal() string { return ie.Token.Val }
+func (ie *IfExpression) expressionNode()     {}
+func (ie *IfExpression) TokenVal() string    { return ie.Token.Val }
+func (ie *IfExpression) Pos() lexer.Position { return ie.Token.Pos }
+func (ie *IfExpression) End() lexer.Position {
+	if ie.FalseBranch != nil {
+		return ie.FalseBranch.End()
+	}
+	if ie.TrueBranch != nil {
+		return ie.TrueBranch.End()
+	}
+	return endOf(ie.Condition, ie.Token)
+}
 func (ie *IfExpression) String() string {
 	var out bytes.Buffer
 
-	out.WriteString("if")
-	out.WriteString(ie.Condition.String())
+	out.WriteString("if ")
+	out.WriteString(condition(ie.Condition))
 
 	out.WriteString(" ")
 	out.WriteString(ie.TrueBranch.String())
 
 	if ie.FalseBranch != nil {
-		out.WriteString("else ")
+		out.WriteString(" else ")
 		out.WriteString(ie.FalseBranch.String())
 	}
 
 	return out.String()
 }
 
+// WhileExpression : iteration
 type WhileExpression struct {
 	Token     lexer.Token // The 'while' token
 	Condition Expression
 	Action    *BlockStatement
 }
 
-func (we *WhileExpression) expressionNode()  {}
-func (we *WhileExpression) TokenVal() string { return we.Token.Val }
+func (we *WhileExpression) expressionNode()     {}
+func (we *WhileExpression) TokenVal() string    { return we.Token.Val }
+func (we *WhileExpression) Pos() lexer.Position { return we.Token.Pos }
+func (we *WhileExpression) End() lexer.Position {
+	if we.Action != nil {
+		return we.Action.End()
+	}
+	return endOf(we.Condition, we.Token)
+}
 func (we *WhileExpression) String() string {
 	var out bytes.Buffer
 
-	out.WriteString("while")
-	out.WriteString(we.Condition.String())
+	out.WriteString("while ")
+	out.WriteString(condition(we.Condition))
 
 	out.WriteString(" ")
 	out.WriteString(we.Action.String())
@@ -212,18 +367,280 @@ func (we *WhileExpression) String() string {
 	return out.String()
 }
 
+// ForExpression : for (init; condition; step) loop, each part may be nil
+type ForExpression struct {
+	Token     lexer.Token // The 'for' token
+	Init      Statement
+	Condition Expression
+	Step      Statement
+	Action    *BlockStatement
+}
+
+func (fe *ForExpression) expressionNode()     {}
+func (fe *ForExpression) TokenVal() string    { return fe.Token.Val }
+func (fe *ForExpression) Pos() lexer.Position { return fe.Token.Pos }
+func (fe *ForExpression) End() lexer.Position {
+	if fe.Action != nil {
+		return fe.Action.End()
+	}
+	return fe.Token.End
+}
+func (fe *ForExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("for (")
+	if fe.Init != nil {
+		out.WriteString(fe.Init.String())
+	}
+	out.WriteString("; ")
+	if fe.Condition != nil {
+		out.WriteString(fe.Condition.String())
+	}
+	out.WriteString("; ")
+	if fe.Step != nil {
+		out.WriteString(fe.Step.String())
+	}
+	out.WriteString(") ")
+	out.WriteString(fe.Action.String())
+
+	return out.String()
+}
+
+// DoWhileExpression : iteration that runs its body before checking the condition
+type DoWhileExpression struct {
+	Token     lexer.Token // The 'do' token
+	Action    *BlockStatement
+	Condition Expression
+}
+
+func (de *DoWhileExpression) expressionNode()     {}
+func (de *DoWhileExpression) TokenVal() string    { return de.Token.Val }
+func (de *DoWhileExpression) Pos() lexer.Position { return de.Token.Pos }
+func (de *DoWhileExpression) End() lexer.Position {
+	if de.Condition != nil {
+		return de.Condition.End()
+	}
+	if de.Action != nil {
+		return de.Action.End()
+	}
+	return de.Token.End
+}
+func (de *DoWhileExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("do ")
+	out.WriteString(de.Action.String())
+	out.WriteString(" while ")
+	out.WriteString(condition(de.Condition))
+
+	return out.String()
+}
+
+// FunctionStatement : func name(parameters) { body }
+type FunctionStatement struct {
+	Token      lexer.Token // the 'func' token
+	Name       *Identifier
+	Parameters []*Identifier
+	Body       *BlockStatement
+}
+
+func (fs *FunctionStatement) statementNode()      {}
+func (fs *FunctionStatement) TokenVal() string    { return fs.Token.Val }
+func (fs *FunctionStatement) Pos() lexer.Position { return fs.Token.Pos }
+func (fs *FunctionStatement) End() lexer.Position { return fs.Body.End() }
+func (fs *FunctionStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("func ")
+	out.WriteString(fs.Name.String())
+	out.WriteString(parameterList(fs.Parameters))
+	out.WriteString(" ")
+	out.WriteString(fs.Body.String())
+
+	return out.String()
+}
+
+// (a, b)
+func parameterList(parameters []*Identifier) string {
+	params := make([]string, len(parameters))
+	for i, p := range parameters {
+		params[i] = p.String()
+	}
+	return "(" + strings.Join(params, ", ") + ")"
+}
+
+// FunctionLiteral : fn(parameters) { body }, an anonymous function value
+type FunctionLiteral struct {
+	Token      lexer.Token // the 'fn' token
+	Parameters []*Identifier
+	Body       *BlockStatement
+}
+
+func (fl *FunctionLiteral) expressionNode()     {}
+func (fl *FunctionLiteral) TokenVal() string    { return fl.Token.Val }
+func (fl *FunctionLiteral) Pos() lexer.Position { return fl.Token.Pos }
+func (fl *FunctionLiteral) End() lexer.Position { return fl.Body.End() }
+func (fl *FunctionLiteral) String() string {
+	return fl.Token.Val + parameterList(fl.Parameters) + " " + fl.Body.String()
+}
+
+// ReturnStatement : leaves the function, Value is nil for a bare return
+type ReturnStatement struct {
+	Token lexer.Token // the 'return' token
+	Value Expression
+}
+
+func (rs *ReturnStatement) statementNode()      {}
+func (rs *ReturnStatement) TokenVal() string    { return rs.Token.Val }
+func (rs *ReturnStatement) Pos() lexer.Position { return rs.Token.Pos }
+func (rs *ReturnStatement) End() lexer.Position { return endOf(rs.Value, rs.Token) }
+func (rs *ReturnStatement) String() string {
+	if rs.Value == nil {
+		return rs.Token.Val
+	}
+	return rs.Token.Val + " " + rs.Value.String()
+}
+
+// CallExpression : function(arguments)
+type CallExpression struct {
+	Token     lexer.Token // the ( token
+	Function  Expression  // identifier or any expression that evaluates to a function
+	Arguments []Expression
+	EndToken  lexer.Token // the ) token
+}
+
+func (ce *CallExpression) expressionNode()     {}
+func (ce *CallExpression) TokenVal() string    { return ce.Token.Val }
+func (ce *CallExpression) Pos() lexer.Position { return ce.Function.Pos() }
+func (ce *CallExpression) End() lexer.Position { return ce.EndToken.End }
+func (ce *CallExpression) String() string {
+	args := make([]string, len(ce.Arguments))
+	for i, a := range ce.Arguments {
+		args[i] = a.String()
+	}
+	return ce.Function.String() + "(" + strings.Join(args, ", ") + ")"
+}
+
+// ArrayLiteral : [elements]
+type ArrayLiteral struct {
+	Token    lexer.Token // the [ token
+	Elements []Expression
+	EndToken lexer.Token // the ] token
+}
+
+func (al *ArrayLiteral) expressionNode()     {}
+func (al *ArrayLiteral) TokenVal() string    { return al.Token.Val }
+func (al *ArrayLiteral) Pos() lexer.Position { return al.Token.Pos }
+func (al *ArrayLiteral) End() lexer.Position { return al.EndToken.End }
+func (al *ArrayLiteral) String() string {
+	elements := make([]string, len(al.Elements))
+	for i, e := range al.Elements {
+		elements[i] = e.String()
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// HashLiteral : {key: value, ...}, a { where an expression is expected
+type HashLiteral struct {
+	Token    lexer.Token // the { token
+	Pairs    []KeyValue  // in the order of the program
+	EndToken lexer.Token // the } token
+}
+
+// KeyValue : one key: value pair of a hash literal
+type KeyValue struct {
+	Key   Expression
+	Value Expression
+}
+
+func (hl *HashLiteral) expressionNode()     {}
+func (hl *HashLiteral) TokenVal() string    { return hl.Token.Val }
+func (hl *HashLiteral) Pos() lexer.Position { return hl.Token.Pos }
+func (hl *HashLiteral) End() lexer.Position { return hl.EndToken.End }
+func (hl *HashLiteral) String() string {
+	pairs := make([]string, len(hl.Pairs))
+	for i, pair := range hl.Pairs {
+		pairs[i] = pair.Key.String() + ": " + pair.Value.String()
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// IndexExpression : left[index]
+type IndexExpression struct {
+	Token    lexer.Token // the [ token
+	Left     Expression
+	Index    Expression
+	EndToken lexer.Token // the ] token
+}
+
+func (ie *IndexExpression) expressionNode()     {}
+func (ie *IndexExpression) TokenVal() string    { return ie.Token.Val }
+func (ie *IndexExpression) Pos() lexer.Position { return ie.Left.Pos() }
+func (ie *IndexExpression) End() lexer.Position { return ie.EndToken.End }
+func (ie *IndexExpression) String() string {
+	return "(" + ie.Left.String() + "[" + ie.Index.String() + "])"
+}
+
+// BreakStatement : leaves the innermost loop
+type BreakStatement struct {
+	Token lexer.Token // the 'break' token
+}
+
+func (bs *BreakStatement) statementNode()      {}
+func (bs *BreakStatement) TokenVal() string    { return bs.Token.Val }
+func (bs *BreakStatement) Pos() lexer.Position { return bs.Token.Pos }
+func (bs *BreakStatement) End() lexer.Position { return bs.Token.End }
+func (bs *BreakStatement) String() string      { return bs.Token.Val }
+
+// ContinueStatement : starts the next iteration of the innermost loop
+type ContinueStatement struct {
+	Token lexer.Token // the 'continue' token
+}
+
+func (cs *ContinueStatement) statementNode()      {}
+func (cs *ContinueStatement) TokenVal() string    { return cs.Token.Val }
+func (cs *ContinueStatement) Pos() lexer.Position { return cs.Token.Pos }
+func (cs *ContinueStatement) End() lexer.Position { return cs.Token.End }
+func (cs *ContinueStatement) String() string      { return cs.Token.Val }
+
+// condition of if and while in parentheses, infix and prefix expressions already have them
+func condition(exp Expression) string {
+	s := exp.String()
+	if strings.HasPrefix(s, "(") {
+		return s
+	}
+	return "(" + s + ")"
+}
+
+// BlockStatement : compound statement, also holds a single statement body of if and while
 type BlockStatement struct {
 	Token      lexer.Token // the { token
 	Statements []Statement
+	EndToken   lexer.Token // the } token
 }
 
-func (bs *BlockStatement) statementNode()   {}
-func (bs *BlockStatement) TokenVal() string { return bs.Token.Val }
+func (bs *BlockStatement) statementNode()      {}
+func (bs *BlockStatement) TokenVal() string    { return bs.Token.Val }
+func (bs *BlockStatement) Pos() lexer.Position { return bs.Token.Pos }
+func (bs *BlockStatement) End() lexer.Position {
+	if bs.EndToken.End.IsValid() {
+		return bs.EndToken.End
+	}
+	if len(bs.Statements) > 0 {
+		return bs.Statements[len(bs.Statements)-1].End()
+	}
+	return bs.Token.End
+}
 func (bs *BlockStatement) String() string {
 	var out bytes.Buffer
 
-	for _, s := range bs.Statements {
+	out.WriteString("{ ")
+	for i, s := range bs.Statements {
+		if i > 0 {
+			out.WriteString("; ")
+		}
 		out.WriteString(s.String())
 	}
+	out.WriteString(" }")
 	return out.String()
 }